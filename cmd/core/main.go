@@ -1,8 +1,11 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/Tfc538/core-cli/internal/cli"
 	"github.com/Tfc538/core-cli/internal/tui"
@@ -21,11 +24,21 @@ func main() {
 	}
 }
 
+// signalContext is cancelled on Ctrl+C or SIGTERM, which cancels in-flight
+// work such as update checks and downloads.
+func signalContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
+
 // runCLI runs the CLI interface.
 func runCLI() {
+	ctx, stop := signalContext()
+
 	rootCmd := cli.NewRootCmd()
 
-	if err := rootCmd.Execute(); err != nil {
+	err := rootCmd.ExecuteContext(ctx)
+	stop()
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
@@ -33,10 +46,19 @@ func runCLI() {
 
 // runTUI launches the interactive TUI.
 func runTUI() {
-	model := tui.New()
-	p := tea.NewProgram(model)
+	ctx, stop := signalContext()
 
-	if _, err := p.Run(); err != nil {
+	model := tui.New(ctx)
+	p := tea.NewProgram(model, tea.WithContext(ctx))
+
+	_, err := p.Run()
+	interrupted := ctx.Err() != nil
+	stop()
+	if interrupted {
+		// Killed by the signal; the terminal is already restored
+		os.Exit(1)
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
@@ -5,4 +5,11 @@ type Info struct {
 	Version   string `json:"version"`
 	Commit    string `json:"commit"`
 	BuildDate string `json:"build_date"`
+	// MinUpgradeFrom is the oldest CLI version that may update directly to
+	// this release.
+	MinUpgradeFrom  string   `json:"min_upgrade_from,omitempty"`
+	BreakingChanges []string `json:"breaking_changes,omitempty"`
+	// MirrorURL is a base URL serving this release's assets as
+	// <mirror_url>/v<version>/<asset>, tried before GitHub.
+	MirrorURL string `json:"mirror_url,omitempty"`
 }
@@ -0,0 +1,198 @@
+package cli
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/Tfc538/core-cli/internal/config"
+	"github.com/Tfc538/core-cli/internal/network"
+	"github.com/spf13/cobra"
+)
+
+// NewDoctorCmd creates the `core doctor` parent command.
+func NewDoctorCmd() *cobra.Command {
+	doctorCmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Diagnose problems with the CORE CLI environment",
+	}
+
+	doctorCmd.AddCommand(NewDoctorNetworkCmd())
+
+	return doctorCmd
+}
+
+// NewDoctorNetworkCmd creates the `core doctor network` command.
+func NewDoctorNetworkCmd() *cobra.Command {
+	var (
+		jsonOutput bool
+		extraURLs  []string
+		timeout    time.Duration
+	)
+
+	networkCmd := &cobra.Command{
+		Use:   "network",
+		Short: "Check connectivity to the update servers",
+		Long: `Connect to the core backend, the release source and every download mirror
+through the same transport the updater uses, honouring the proxy, CA bundle,
+client certificate and timeouts in network.json, and report where each
+connection fails.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDoctorNetwork(cmd.Context(), jsonOutput, extraURLs, timeout)
+		},
+	}
+
+	networkCmd.Flags().BoolVar(&jsonOutput, "json", false, "Output as JSON")
+	networkCmd.Flags().StringSliceVar(&extraURLs, "url", nil, "Additional URL to check (repeatable)")
+	networkCmd.Flags().DurationVar(&timeout, "timeout", 15*time.Second, "Time limit per URL")
+
+	return networkCmd
+}
+
+// doctorNetworkReport is the JSON output of `core doctor network`.
+type doctorNetworkReport struct {
+	CABundle   string                `json:"ca_bundle,omitempty"`
+	ClientCert string                `json:"client_cert,omitempty"`
+	Proxy      string                `json:"proxy,omitempty"`
+	Checks     []network.ProbeResult `json:"checks"`
+}
+
+// runDoctorNetwork probes the update endpoints.
+func runDoctorNetwork(ctx context.Context, jsonOutput bool, extraURLs []string, timeout time.Duration) error {
+	out := NewOutputHelper()
+
+	settings, err := loadHTTPSettings()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadUpdate()
+	if err != nil {
+		return fmt.Errorf("failed to load update config: %w", err)
+	}
+
+	channel, err := resolveChannel("", cfg)
+	if err != nil {
+		return err
+	}
+
+	checker := newUpdateChecker(channel, cfg, settings, false)
+
+	report := doctorNetworkReport{
+		CABundle: settings.options.CABundle,
+		Proxy:    settings.options.Proxy,
+	}
+	if report.Proxy == "" {
+		report.Proxy = proxyFromEnvironment()
+	}
+	if settings.options.ClientCert != "" {
+		report.ClientCert = describeClientCert(settings.transport.TLSClientConfig)
+	}
+
+	failed := 0
+	for _, endpoint := range append(checker.Endpoints(), extraURLs...) {
+		// Local mirrors need no network
+		if u, err := url.Parse(endpoint); err == nil && u.Scheme == "file" {
+			continue
+		}
+
+		probeCtx, cancel := context.WithTimeout(ctx, timeout)
+		result := network.Probe(probeCtx, settings.transport, endpoint)
+		cancel()
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !result.OK() {
+			failed++
+		}
+		report.Checks = append(report.Checks, result)
+	}
+
+	if jsonOutput {
+		if err := outputJSON(report); err != nil {
+			return err
+		}
+	} else {
+		printNetworkReport(out, report)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d endpoints unreachable", failed, len(report.Checks))
+	}
+	return nil
+}
+
+// printNetworkReport prints the human-readable report.
+func printNetworkReport(out *OutputHelper, report doctorNetworkReport) {
+	out.Table("CA bundle", valueOr(report.CABundle, "system roots"))
+	out.Table("Client certificate", valueOr(report.ClientCert, "none"))
+	out.Table("Proxy", valueOr(report.Proxy, "direct"))
+
+	for _, check := range report.Checks {
+		out.Separator()
+		if check.OK() {
+			out.Success(fmt.Sprintf("%s (HTTP %d, %s)", check.URL, check.Status, check.Total.Round(time.Millisecond)))
+		} else {
+			out.Error(check.URL)
+		}
+
+		if check.Proxy != "" {
+			out.Table("  Proxy", check.Proxy)
+		}
+		if check.DNS > 0 {
+			out.Table("  DNS", check.DNS.Round(time.Millisecond).String())
+		}
+		if check.Connect > 0 {
+			out.Table("  Connect", check.Connect.Round(time.Millisecond).String())
+		}
+		if check.TLSVersion != "" {
+			out.Table("  TLS", fmt.Sprintf("%s in %s", check.TLSVersion, check.TLS.Round(time.Millisecond)))
+		}
+		if check.Error != "" {
+			out.Table("  Error", check.Error)
+		}
+		if check.Hint != "" {
+			out.Table("  Hint", check.Hint)
+		}
+	}
+}
+
+// describeClientCert summarises the configured client certificate.
+func describeClientCert(config *tls.Config) string {
+	if config == nil || len(config.Certificates) == 0 || len(config.Certificates[0].Certificate) == 0 {
+		return ""
+	}
+
+	cert, err := x509.ParseCertificate(config.Certificates[0].Certificate[0])
+	if err != nil {
+		return "unparseable certificate"
+	}
+
+	desc := fmt.Sprintf("%s (expires %s)", cert.Subject.CommonName, cert.NotAfter.Format("2006-01-02"))
+	if time.Now().After(cert.NotAfter) {
+		desc += " EXPIRED"
+	}
+	return desc
+}
+
+// proxyFromEnvironment returns the proxy set in the environment, if any.
+func proxyFromEnvironment() string {
+	for _, key := range []string{"HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy"} {
+		if value := os.Getenv(key); value != "" {
+			return value + " (from " + key + ")"
+		}
+	}
+	return ""
+}
+
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
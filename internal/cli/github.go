@@ -1,13 +0,0 @@
-package cli
-
-import "os"
-
-func githubToken() string {
-	if token := os.Getenv("CORE_GITHUB_TOKEN"); token != "" {
-		return token
-	}
-	if token := os.Getenv("GH_TOKEN"); token != "" {
-		return token
-	}
-	return os.Getenv("GITHUB_TOKEN")
-}
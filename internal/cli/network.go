@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Tfc538/core-cli/internal/config"
+	"github.com/Tfc538/core-cli/internal/network"
+)
+
+// httpSettings is the network configuration shared by every HTTP client of
+// the CLI: the update checker, the core backend client and the downloader.
+type httpSettings struct {
+	options         network.Options
+	transport       *http.Transport
+	checkTimeout    time.Duration
+	downloadTimeout time.Duration
+}
+
+// loadHTTPSettings builds the shared transport from network.json.
+func loadHTTPSettings() (httpSettings, error) {
+	cfg, err := config.LoadNetwork()
+	if err != nil {
+		return httpSettings{}, err
+	}
+
+	opts, err := cfg.Options()
+	if err != nil {
+		return httpSettings{}, err
+	}
+
+	checkTimeout, downloadTimeout, err := cfg.Timeouts()
+	if err != nil {
+		return httpSettings{}, err
+	}
+
+	transport, err := network.NewTransport(opts)
+	if err != nil {
+		return httpSettings{}, fmt.Errorf("invalid network config: %w", err)
+	}
+
+	return httpSettings{
+		options:         opts,
+		transport:       transport,
+		checkTimeout:    checkTimeout,
+		downloadTimeout: downloadTimeout,
+	}, nil
+}
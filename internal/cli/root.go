@@ -21,6 +21,7 @@ All commands can be run headlessly via arguments, or launch an interactive TUI w
 	// Add subcommands
 	rootCmd.AddCommand(NewVersionCmd())
 	rootCmd.AddCommand(NewUpdateCmd())
+	rootCmd.AddCommand(NewDoctorCmd())
 
 	return rootCmd
 }
@@ -1,6 +1,11 @@
 package cli
 
 import (
+	"errors"
+	"fmt"
+
+	"github.com/Tfc538/core-cli/internal/config"
+	"github.com/Tfc538/core-cli/internal/engine/update"
 	"github.com/spf13/cobra"
 )
 
@@ -15,6 +20,132 @@ func NewUpdateCmd() *cobra.Command {
 	// Add subcommands
 	updateCmd.AddCommand(NewUpdateCheckCmd())
 	updateCmd.AddCommand(NewUpdateApplyCmd())
+	updateCmd.AddCommand(NewUpdateDownloadCmd())
+	updateCmd.AddCommand(NewUpdateChannelCmd())
+	updateCmd.AddCommand(NewUpdateRollbackCmd())
+	updateCmd.AddCommand(NewUpdateHistoryCmd())
+	updateCmd.AddCommand(NewUpdateDoctorCmd())
 
 	return updateCmd
 }
+
+// resolveChannel returns the release channel to use: the --channel flag if
+// given, otherwise the persisted choice, otherwise stable.
+func resolveChannel(flag string, cfg config.UpdateConfig) (update.Channel, error) {
+	if flag != "" {
+		return update.ParseChannel(flag)
+	}
+	return update.ParseChannel(cfg.Channel)
+}
+
+// checkUpdateLock fails early when another process is updating CORE CLI,
+// before prompting or downloading. The updater takes the lock itself.
+func checkUpdateLock(out *OutputHelper) error {
+	stateDir, err := config.StateDir()
+	if err != nil {
+		return nil
+	}
+	if err := update.CheckLock(stateDir); err != nil {
+		reportUpdateLocked(out, err)
+		return err
+	}
+	return nil
+}
+
+// reportUpdateLocked explains a lock held by another update, if err is one.
+// It reports whether it did.
+func reportUpdateLocked(out *OutputHelper, err error) bool {
+	var locked *update.LockedError
+	if !errors.As(err, &locked) {
+		return false
+	}
+	out.Error(locked.Error())
+	out.Info("Wait for it to finish. If that process is stuck, stop it; the lock is released when it exits.")
+	return true
+}
+
+// describeCheckError explains a failed update check with the next step to
+// take, e.g. "GitHub rate limited until 14:05; set CORE_GITHUB_TOKEN for a
+// higher limit". Other errors are returned as they are.
+func describeCheckError(err error, cfg config.UpdateConfig) string {
+	if errors.Is(err, update.ErrReleaseNotFound) {
+		return err.Error() + "; check the version, or try again once the release is published"
+	}
+	if errors.Is(err, update.ErrInsecureManifest) {
+		return err.Error() + "; serve it over https, or set allow_insecure_manifest in update.json to accept it"
+	}
+
+	var apiErr *update.APIError
+	if !errors.As(err, &apiErr) {
+		return err.Error()
+	}
+
+	// Only the hosts with tokens get token advice
+	tokenEnv, token := "", ""
+	switch apiErr.Host {
+	case "GitHub":
+		tokenEnv, token = "CORE_GITHUB_TOKEN", config.GitHubToken()
+	case "GitLab", "Gitea":
+		tokenEnv, token = "CORE_UPDATE_TOKEN", config.SourceToken()
+	}
+	owner, repo := cfg.Repo()
+
+	switch {
+	case errors.Is(err, update.ErrRateLimited):
+		msg := apiErr.Host + " rate limited"
+		if !apiErr.RetryAt.IsZero() {
+			msg += " until " + apiErr.RetryAt.Local().Format("15:04")
+		}
+		if tokenEnv != "" && token == "" {
+			return msg + "; set " + tokenEnv + " for a higher limit"
+		}
+		return msg + "; try again later"
+
+	case errors.Is(err, update.ErrUnauthorized):
+		if tokenEnv == "" {
+			return apiErr.Error()
+		}
+		if token == "" {
+			return fmt.Sprintf("%s denied access (%s); set %s to a token that can read %s/%s", apiErr.Host, apiErr.Message, tokenEnv, owner, repo)
+		}
+		return fmt.Sprintf("%s rejected the token (%s); check that %s is valid and can read %s/%s", apiErr.Host, apiErr.Message, tokenEnv, owner, repo)
+
+	case errors.Is(err, update.ErrRepoNotFound):
+		if tokenEnv == "" {
+			return apiErr.Error() + "; check source_url in update.json"
+		}
+		msg := fmt.Sprintf("%s/%s or its release was not found on %s; check repository in update.json", owner, repo, apiErr.Host)
+		if token == "" {
+			return msg + ", or set " + tokenEnv + " if it is private"
+		}
+		return msg + " and that the token can read it"
+
+	case errors.Is(err, update.ErrNetwork):
+		return apiErr.Error() + "; check your connection and the proxy settings in network.json"
+	}
+
+	return err.Error()
+}
+
+// newUpdateHistory opens the retained-binary history in the state directory.
+func newUpdateHistory(cfg config.UpdateConfig) (*update.History, error) {
+	stateDir, err := config.StateDir()
+	if err != nil {
+		return nil, err
+	}
+	return update.NewHistory(stateDir, cfg.Backups()), nil
+}
+
+// newUpdateChecker creates the update checker shared by the update commands.
+// Responses are cached in the user cache directory unless refresh is set.
+func newUpdateChecker(channel update.Channel, cfg config.UpdateConfig, settings httpSettings, refresh bool) *update.Checker {
+	return update.NewChecker(updateCheckerConfig(channel, cfg, settings, refresh))
+}
+
+// updateCheckerConfig returns the configuration of newUpdateChecker, for
+// commands that adjust it, e.g. to resolve assets for another platform.
+func updateCheckerConfig(channel update.Channel, cfg config.UpdateConfig, settings httpSettings, refresh bool) update.CheckerConfig {
+	checkerConfig := cfg.CheckerConfig(channel, settings.transport, settings.checkTimeout)
+	checkerConfig.Refresh = refresh
+	return checkerConfig
+}
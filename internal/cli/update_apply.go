@@ -2,56 +2,196 @@ package cli
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
 
+	"github.com/Tfc538/core-cli/internal/config"
 	"github.com/Tfc538/core-cli/internal/engine/update"
 	"github.com/Tfc538/core-cli/internal/version"
 	"github.com/spf13/cobra"
 )
 
+// updateApplyOptions holds the flags of `core update apply`.
+type updateApplyOptions struct {
+	skipConfirm    bool
+	insecureSkip   bool
+	channel        string
+	version        string
+	allowDowngrade bool
+	refresh        bool
+	force          bool
+	fromFile       string
+	skipPreflight  bool
+	jsonOutput     bool
+	dryRun         bool
+}
+
 // NewUpdateApplyCmd creates the `core update apply` command.
 func NewUpdateApplyCmd() *cobra.Command {
-	var skipConfirm bool
+	var opts updateApplyOptions
 
 	applyCmd := &cobra.Command{
 		Use:   "apply",
 		Short: "Apply the latest CORE CLI update",
-		Long:  "Download and apply the latest version of CORE CLI, replacing the current binary.",
+		Long: `Download and apply the latest version of CORE CLI, replacing the current binary.
+
+Use --version to install a specific release instead, e.g. to roll back a
+regression. Installing an older release requires --allow-downgrade.
+
+Updates the running version cannot move to directly, such as a new major
+version, are refused unless --force is given.
+
+Before anything is downloaded, the same checks as 'core update doctor' run;
+an update is refused if they fail, for example when the binary belongs to a
+package manager, unless --skip-preflight is given.
+
+Use --from-file to install an offline bundle written by 'core update download'
+without network access. It is verified like a downloaded release.
+
+Use --dry-run to see what an update would do without changing anything: the
+release source and version, the asset and the mirrors it would be downloaded
+from, whether a checksum and signature are published, where the binary would
+be replaced and the old one kept, and the preflight results. Only release
+metadata and the checksum file are fetched. The command fails if the update
+would be refused.
+
+With --json (which requires --yes, except with --dry-run), stdout carries
+one JSON object per line: a "progress" event for each step, then a "result"
+with the old and new version and where the previous binary was kept.
+Progress stages are downloading, resuming, retrying, fallback, verifying,
+verified, warning, extracting, replacing, verifying-install, complete and
+failed; failures carry a stable error_code such as checksum_mismatch or
+rate_limited. Errors are also written to stderr. With --dry-run, a "plan"
+object takes the place of the progress events.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runUpdateApply(skipConfirm)
+			return runUpdateApply(cmd.Context(), opts)
 		},
 	}
 
-	applyCmd.Flags().BoolVar(&skipConfirm, "yes", false, "Skip confirmation prompt")
+	applyCmd.Flags().BoolVar(&opts.skipConfirm, "yes", false, "Skip confirmation prompt")
+	applyCmd.Flags().StringVar(&opts.channel, "channel", "", "Release channel to update from (stable, beta, nightly)")
+	applyCmd.Flags().StringVar(&opts.version, "version", "", "Install a specific version instead of the latest")
+	applyCmd.Flags().BoolVar(&opts.allowDowngrade, "allow-downgrade", false, "Allow installing a version older than the current one")
+	applyCmd.Flags().BoolVar(&opts.force, "force", false, "Install even if the release is marked incompatible with this version")
+	applyCmd.Flags().BoolVar(&opts.refresh, "refresh", false, "Ignore the cached update check and query the server")
+	applyCmd.Flags().BoolVar(&opts.insecureSkip, "insecure-skip-verify", false, "Install without verifying checksums or signatures")
+	applyCmd.Flags().StringVar(&opts.fromFile, "from-file", "", "Install from an offline bundle directory")
+	applyCmd.Flags().BoolVar(&opts.skipPreflight, "skip-preflight", false, "Update even if preflight checks fail, e.g. over a package-managed binary")
+	applyCmd.Flags().BoolVar(&opts.jsonOutput, "json", false, "Report progress and the result as JSON lines (requires --yes)")
+	applyCmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "Show what the update would do without changing anything")
+	applyCmd.MarkFlagsMutuallyExclusive("from-file", "version")
+	applyCmd.MarkFlagsMutuallyExclusive("from-file", "channel")
+	applyCmd.MarkFlagsMutuallyExclusive("from-file", "refresh")
 
 	return applyCmd
 }
 
 // runUpdateApply performs the update application.
-func runUpdateApply(skipConfirm bool) error {
+func runUpdateApply(ctx context.Context, opts updateApplyOptions) (err error) {
 	out := NewOutputHelper()
 
-	// First, check for available updates
-	checker := update.NewChecker(update.CheckerConfig{
-		APIBaseURL:       os.Getenv("CORE_UPDATE_API_BASE"),
-		GitHubAPIBaseURL: os.Getenv("CORE_GITHUB_API_BASE"),
-		GitHubOwner:      "Tfc538",
-		GitHubRepo:       "core-cli",
-		CurrentVersion:   version.Version,
-		GitHubToken:      githubToken(),
-	})
+	// With --json every outcome ends in a result line
+	var result updateResult
+	if opts.jsonOutput {
+		if !opts.skipConfirm && !opts.dryRun {
+			return fmt.Errorf("--json requires --yes, since it cannot prompt")
+		}
+		out = newJSONOutputHelper()
+		result.OldVersion = version.Version
+		defer func() { writeUpdateResult(result, err) }()
+	}
 
-	info, err := checker.Check()
+	cfg, err := config.LoadUpdate()
 	if err != nil {
-		out.Error(fmt.Sprintf("Check failed: %v", err))
-		return fmt.Errorf("failed to check for updates: %w", err)
+		return fmt.Errorf("failed to load update config: %w", err)
+	}
+
+	channel, err := resolveChannel(opts.channel, cfg)
+	if err != nil {
+		return err
+	}
+
+	settings, err := loadHTTPSettings()
+	if err != nil {
+		return err
+	}
+
+	if err := checkUpdateLock(out); err != nil {
+		return err
+	}
+
+	// First, resolve the release to install
+	var info *update.UpdateInfo
+	var source string
+	if opts.fromFile != "" {
+		source = "bundle " + opts.fromFile
+		info, err = bundleUpdateInfo(opts.fromFile)
+		if err != nil {
+			out.Error(err.Error())
+			return err
+		}
+	} else {
+		source = releaseSourceName(cfg)
+
+		checkerConfig := updateCheckerConfig(channel, cfg, settings, opts.refresh)
+		if opts.dryRun {
+			// A dry run leaves the update-check cache alone
+			checkerConfig.CacheDir = ""
+		}
+		checker := update.NewChecker(checkerConfig)
+
+		if opts.version != "" {
+			info, err = checker.CheckVersionContext(ctx, opts.version)
+		} else {
+			info, err = checker.CheckContext(ctx)
+		}
+		if err != nil {
+			out.Error("Check failed: " + describeCheckError(err, cfg))
+			return fmt.Errorf("failed to check for updates: %w", err)
+		}
 	}
 
-	if !info.UpdateAvailable {
+	// A bundle names its version just like --version does
+	pinned := opts.version != "" || opts.fromFile != ""
+	result.NewVersion = info.LatestVersion
+
+	switch {
+	case !pinned && !info.UpdateAvailable:
 		out.Info("You are already on the latest version.")
+		result.Status = applyUpToDate
+		return nil
+	case info.Direction == update.DirectionNone:
+		out.Info(fmt.Sprintf("CORE CLI v%s is already installed.", info.LatestVersion))
+		result.Status = applyUpToDate
 		return nil
+	case info.Direction == update.DirectionDowngrade && !opts.allowDowngrade:
+		out.Error(fmt.Sprintf("v%s is older than the installed v%s", info.LatestVersion, info.CurrentVersion))
+		result.ErrorCode = "downgrade_refused"
+		return fmt.Errorf("refusing to downgrade without --allow-downgrade")
+	}
+
+	if info.DownloadURL == "" {
+		out.Warning(fmt.Sprintf("v%s has no downloadable assets yet; try again once the release is published.", info.LatestVersion))
+		result.ErrorCode = "no_asset"
+		return fmt.Errorf("no release asset to install")
+	}
+
+	downgrade := info.Direction == update.DirectionDowngrade
+
+	if !info.Compatible && !downgrade {
+		showBreakingChanges(out, info)
+		if !opts.force {
+			out.Error(fmt.Sprintf("v%s cannot be installed directly over v%s", info.LatestVersion, info.CurrentVersion))
+			if info.SteppingStone != "" {
+				out.Info(fmt.Sprintf("Update to v%s first: core update apply --version %s", info.SteppingStone, info.SteppingStone))
+			}
+			result.ErrorCode = "incompatible"
+			return fmt.Errorf("refusing incompatible update without --force")
+		}
+		out.Warning("Installing an incompatible release (--force)")
 	}
 
 	// Get current binary path
@@ -61,15 +201,70 @@ func runUpdateApply(skipConfirm bool) error {
 		return fmt.Errorf("failed to determine current binary path: %w", err)
 	}
 
+	stateDir, err := config.StateDir()
+	if err != nil {
+		out.Warning(fmt.Sprintf("Previous binary will not be retained: %v", err))
+	}
+
+	// Catch permission, disk space and package manager problems before
+	// anything is downloaded
+	preflight := update.Preflight(update.PreflightConfig{
+		TargetPath: binaryPath,
+		AssetSize:  info.Size,
+		StateDir:   stateDir,
+	})
+	if opts.dryRun {
+		updater := update.NewUpdater(applyUpdaterConfig(info, preflight.ResolvedPath, stateDir, cfg, settings, opts.insecureSkip))
+		if err := runUpdatePlan(ctx, out, opts, source, info, preflight, updater); err != nil {
+			return err
+		}
+		result.Status = applyPlanned
+		return nil
+	}
+
+	printPreflightProblems(out, preflight)
+	if err := preflight.Err(); err != nil {
+		if !opts.skipPreflight {
+			out.Error("Preflight failed; nothing was changed. Run 'core update doctor' for details.")
+			return err
+		}
+		out.Warning("Continuing despite failed preflight checks (--skip-preflight)")
+	}
+	binaryPath = preflight.ResolvedPath
+
 	// Show confirmation prompt
-	if !skipConfirm {
-		out.Heading("Update Available")
+	if !opts.skipConfirm {
+		if downgrade {
+			out.Heading("Downgrade Requested")
+		} else {
+			out.Heading("Update Available")
+		}
 		out.Table("Current version", info.CurrentVersion)
-		out.Table("Latest version", info.LatestVersion)
+		if pinned {
+			out.Table("Target version", info.LatestVersion)
+		} else {
+			out.Table("Latest version", info.LatestVersion)
+		}
+		if info.Channel != update.ChannelStable {
+			out.Table("Channel", string(info.Channel))
+		}
+		if opts.fromFile != "" {
+			out.Table("Bundle", opts.fromFile)
+		}
 		out.Table("Target location", binaryPath)
 		out.Separator()
 
-		response := promptUser("Continue with update? [y/N]: ")
+		if info.Compatible && len(info.BreakingChanges) > 0 {
+			showBreakingChanges(out, info)
+		}
+
+		if downgrade {
+			out.Warning(fmt.Sprintf("This downgrades CORE CLI from v%s to v%s. Newer features and fixes will be lost.",
+				info.CurrentVersion, info.LatestVersion))
+			out.Separator()
+		}
+
+		response := promptUser(ctx, "Continue with update? [y/N]: ")
 		if response != "y" && response != "Y" {
 			out.Info("Update cancelled.")
 			return nil
@@ -80,51 +275,187 @@ func runUpdateApply(skipConfirm bool) error {
 	out.Progress("Starting update")
 	out.Separator()
 
+	if opts.insecureSkip {
+		out.Warning("Skipping checksum and signature verification (--insecure-skip-verify)")
+	}
+
 	// Create updater and set up progress reporting
-	updater := update.NewUpdater(update.UpdaterConfig{
-		DownloadURL: info.DownloadURL,
-		ChecksumURL: info.ChecksumURL,
-		TargetPath:  binaryPath,
-	})
+	updater := update.NewUpdater(applyUpdaterConfig(info, binaryPath, stateDir, cfg, settings, opts.insecureSkip))
+
+	// The terminal renderer and the JSON emitter are alternative consumers
+	// of the same progress events
+	if opts.jsonOutput {
+		updater.Subscribe(writeUpdateEvent)
+	} else {
+		updater.Subscribe(applyProgressRenderer(out))
+	}
+
+	// Apply the update
+	if err := updater.ApplyContext(ctx); err != nil {
+		if errors.Is(err, context.Canceled) {
+			out.Warning("Update cancelled, the current binary was left unchanged.")
+			result.ErrorCode = update.ErrorCode(context.Canceled)
+			return fmt.Errorf("update cancelled")
+		}
+		if reportUpdateLocked(out, err) {
+			return err
+		}
+		if errors.Is(err, update.ErrSelfTestFailed) {
+			out.Warning(fmt.Sprintf("v%s did not start correctly; v%s was restored.", info.LatestVersion, version.Version))
+		}
+		out.Error(fmt.Sprintf("Apply failed: %v", err))
+		return fmt.Errorf("update failed: %w", err)
+	}
+
+	result.BackupPath = updater.BackupPath()
+
+	out.Separator()
+	if downgrade {
+		result.Status = applyDowngraded
+		out.Info(fmt.Sprintf("✓ CORE CLI downgraded to v%s", info.LatestVersion))
+	} else {
+		result.Status = applyUpdated
+		out.Info(fmt.Sprintf("✓ CORE CLI updated to v%s", info.LatestVersion))
+	}
+	return nil
+}
+
+// applyUpdaterConfig returns the configuration of the updater installing
+// info over binaryPath. Dry runs plan with the same configuration, and
+// `core update download` verifies bundles with it.
+func applyUpdaterConfig(info *update.UpdateInfo, binaryPath, stateDir string, cfg config.UpdateConfig,
+	settings httpSettings, insecureSkip bool) update.UpdaterConfig {
+	checksumPolicy := update.ChecksumRequire
+	publicKey := version.UpdatePublicKey
+	if insecureSkip {
+		checksumPolicy = update.ChecksumSkip
+		publicKey = ""
+	}
+	source, sourceURL := cfg.ReleaseSource()
+
+	return update.UpdaterConfig{
+		DownloadURL:             info.DownloadURL,
+		DownloadURLs:            info.DownloadURLs,
+		ChecksumURL:             info.ChecksumURL,
+		SHA256:                  info.SHA256,
+		TargetPath:              binaryPath,
+		AssetName:               info.AssetName,
+		ChecksumPolicy:          checksumPolicy,
+		SignatureURL:            info.SignatureURL,
+		ChecksumSignatureURL:    info.ChecksumSignatureURL,
+		AssetAPIURL:             info.AssetAPIURL,
+		ChecksumAPIURL:          info.ChecksumAPIURL,
+		SignatureAPIURL:         info.SignatureAPIURL,
+		ChecksumSignatureAPIURL: info.ChecksumSignatureAPIURL,
+		GitHubToken:             config.GitHubToken(),
+		SourceType:              update.SourceType(source),
+		SourceURL:               sourceURL,
+		SourceToken:             config.SourceToken(),
+		PublicKey:               publicKey,
+		StateDir:                stateDir,
+		KeepBackups:             cfg.Backups(),
+		CurrentVersion:          version.Version,
+		CurrentCommit:           version.GitCommit,
+		Transport:               settings.transport,
+		Timeout:                 settings.downloadTimeout,
+		ExpectedVersion:         info.LatestVersion,
+		ModulePath:              version.ModulePath,
+	}
+}
 
-	updater.SetProgressCallback(func(progress update.UpdateProgress) {
+// applyProgressRenderer returns the subscriber printing update progress
+// for `core update apply`.
+func applyProgressRenderer(out *OutputHelper) update.ProgressCallback {
+	return func(progress update.UpdateProgress) {
 		switch progress.Stage {
-		case "downloading":
+		case update.StageDownloading:
 			if progress.BytesTotal > 0 {
 				mb := progress.BytesDone / 1024 / 1024
 				totalMB := progress.BytesTotal / 1024 / 1024
-				fmt.Printf("⬇  Downloading... %d%% (%d/%d MB)\r",
-					progress.Percent, mb, totalMB)
+				fmt.Printf("⬇  Downloading from %s... %d%% (%d/%d MB)\r",
+					progress.Mirror, progress.Percent, mb, totalMB)
 			}
-		case "verifying":
+		case update.StageResuming:
+			out.Progress(fmt.Sprintf("Resuming download from %s at %d MB", progress.Mirror, progress.BytesDone/1024/1024))
+		case update.StageRetrying:
+			fmt.Println("                                        ")
+			out.Warning(fmt.Sprintf("Download interrupted (%v), retrying (attempt %d)", progress.Error, progress.Attempt))
+		case update.StageFallback:
+			fmt.Println("                                        ")
+			out.Warning(fmt.Sprintf("Download failed (%v), trying mirror %s", progress.Error, progress.Mirror))
+		case update.StageVerifying:
 			fmt.Println("                                        ")
 			out.Progress("Verifying checksum")
-		case "replacing":
+		case update.StageVerified:
 			out.Success("Checksum verified")
+		case update.StageWarning:
+			if errors.Is(progress.Error, update.ErrChecksumUnavailable) || errors.Is(progress.Error, update.ErrChecksumNotFound) {
+				out.Warning(fmt.Sprintf("Checksum not verified: %v", progress.Error))
+			} else {
+				out.Warning(progress.Error.Error())
+			}
+		case update.StageExtracting:
+			out.Progress("Extracting binary")
+		case update.StageReplacing:
 			out.Progress("Replacing binary")
-		case "complete":
+		case update.StageVerifyingInstall:
+			out.Progress("Checking the installed binary")
+		case update.StageComplete:
 			fmt.Println("                                        ")
 			out.Success("Update complete!")
-		case "failed":
+		case update.StageFailed:
 			out.Error(fmt.Sprintf("Update failed: %v", progress.Error))
 		}
-	})
+	}
+}
 
-	// Apply the update
-	if err := updater.Apply(); err != nil {
-		out.Error(fmt.Sprintf("Apply failed: %v", err))
-		return fmt.Errorf("update failed: %w", err)
+// bundleUpdateInfo opens the offline bundle at path and checks that it was
+// made for this system. Finer differences such as the ARM variant are left
+// to the updater's binary validation.
+func bundleUpdateInfo(path string) (*update.UpdateInfo, error) {
+	bundle, err := update.OpenBundle(path)
+	if err != nil {
+		return nil, err
+	}
+
+	current := update.CurrentPlatform()
+	if target, err := update.ParsePlatform(bundle.Platform); err != nil || target.OS != current.OS || target.Arch != current.Arch {
+		return nil, fmt.Errorf("bundle is for %s, this system is %s", bundle.Platform, current)
+	}
+
+	return bundle.UpdateInfo(version.Version), nil
+}
+
+// showBreakingChanges lists the breaking changes declared for a release.
+func showBreakingChanges(out *OutputHelper, info *update.UpdateInfo) {
+	if len(info.BreakingChanges) == 0 {
+		return
 	}
 
+	out.Warning(fmt.Sprintf("v%s contains breaking changes:", info.LatestVersion))
+	for _, change := range info.BreakingChanges {
+		out.Info("  - " + change)
+	}
 	out.Separator()
-	fmt.Printf("✓ CORE CLI updated to v%s\n", info.LatestVersion)
-	return nil
 }
 
 // promptUser prompts the user for input and returns the response.
-func promptUser(prompt string) string {
+// It returns an empty response when ctx is cancelled while waiting.
+func promptUser(ctx context.Context, prompt string) string {
 	fmt.Print(prompt)
-	reader := bufio.NewReader(os.Stdin)
-	response, _ := reader.ReadString('\n')
-	return strings.TrimSpace(response)
+
+	answer := make(chan string, 1)
+	go func() {
+		reader := bufio.NewReader(os.Stdin)
+		response, _ := reader.ReadString('\n')
+		answer <- strings.TrimSpace(response)
+	}()
+
+	select {
+	case response := <-answer:
+		return response
+	case <-ctx.Done():
+		fmt.Println()
+		return ""
+	}
 }
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/Tfc538/core-cli/internal/config"
+	"github.com/Tfc538/core-cli/internal/engine/update"
+	"github.com/spf13/cobra"
+)
+
+// NewUpdateChannelCmd creates the `core update channel` command.
+func NewUpdateChannelCmd() *cobra.Command {
+	channelCmd := &cobra.Command{
+		Use:   "channel [stable|beta|nightly]",
+		Short: "Show or set the release channel",
+		Long: `Show the release channel used by update checks, or persist a new one.
+
+stable follows full releases only, beta additionally offers -beta.N
+prereleases, and nightly also offers -nightly.YYYYMMDD builds.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return runUpdateChannelShow()
+			}
+			return runUpdateChannelSet(args[0])
+		},
+	}
+
+	return channelCmd
+}
+
+// runUpdateChannelShow prints the persisted release channel.
+func runUpdateChannelShow() error {
+	cfg, err := config.LoadUpdate()
+	if err != nil {
+		return fmt.Errorf("failed to load update config: %w", err)
+	}
+
+	channel, err := resolveChannel("", cfg)
+	if err != nil {
+		return fmt.Errorf("failed to load release channel: %w", err)
+	}
+
+	NewOutputHelper().Table("Release channel", string(channel))
+	return nil
+}
+
+// runUpdateChannelSet validates and persists a release channel.
+func runUpdateChannelSet(name string) error {
+	channel, err := update.ParseChannel(name)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadUpdate()
+	if err != nil {
+		return fmt.Errorf("failed to load update config: %w", err)
+	}
+
+	cfg.Channel = string(channel)
+	if err := config.SaveUpdate(cfg); err != nil {
+		return err
+	}
+
+	NewOutputHelper().Success(fmt.Sprintf("Release channel set to %s", channel))
+	return nil
+}
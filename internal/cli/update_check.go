@@ -1,45 +1,62 @@
 package cli
 
 import (
+	"context"
 	"fmt"
-	"os"
 
+	"github.com/Tfc538/core-cli/internal/config"
 	"github.com/Tfc538/core-cli/internal/engine/update"
-	"github.com/Tfc538/core-cli/internal/version"
 	"github.com/spf13/cobra"
 )
 
 // NewUpdateCheckCmd creates the `core update check` command.
 func NewUpdateCheckCmd() *cobra.Command {
-	var jsonOutput bool
+	var (
+		jsonOutput bool
+		channel    string
+		refresh    bool
+	)
 
 	checkCmd := &cobra.Command{
 		Use:   "check",
 		Short: "Check for available CORE CLI updates",
 		Long:  "Check the GitHub Releases to see if a newer version of CORE CLI is available.",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runUpdateCheck(jsonOutput)
+			return runUpdateCheck(cmd.Context(), jsonOutput, channel, refresh)
 		},
 	}
 
 	checkCmd.Flags().BoolVar(&jsonOutput, "json", false, "Output as JSON")
+	checkCmd.Flags().StringVar(&channel, "channel", "", "Release channel to check (stable, beta, nightly)")
+	checkCmd.Flags().BoolVar(&refresh, "refresh", false, "Ignore the cached result and query the server")
 
 	return checkCmd
 }
 
 // runUpdateCheck performs the update check.
-func runUpdateCheck(jsonOutput bool) error {
-	checker := update.NewChecker(update.CheckerConfig{
-		APIBaseURL:       os.Getenv("CORE_UPDATE_API_BASE"),
-		GitHubAPIBaseURL: os.Getenv("CORE_GITHUB_API_BASE"),
-		GitHubOwner:      "Tfc538",
-		GitHubRepo:       "core-cli",
-		CurrentVersion:   version.Version,
-		GitHubToken:      githubToken(),
-	})
-
-	info, err := checker.Check()
+func runUpdateCheck(ctx context.Context, jsonOutput bool, channelFlag string, refresh bool) error {
+	cfg, err := config.LoadUpdate()
 	if err != nil {
+		return fmt.Errorf("failed to load update config: %w", err)
+	}
+
+	channel, err := resolveChannel(channelFlag, cfg)
+	if err != nil {
+		return err
+	}
+
+	settings, err := loadHTTPSettings()
+	if err != nil {
+		return err
+	}
+
+	checker := newUpdateChecker(channel, cfg, settings, refresh)
+
+	info, err := checker.CheckContext(ctx)
+	if err != nil {
+		if !jsonOutput {
+			NewOutputHelper().Error(describeCheckError(err, cfg))
+		}
 		return fmt.Errorf("update check failed: %w", err)
 	}
 
@@ -52,11 +69,25 @@ func runUpdateCheck(jsonOutput bool) error {
 
 	out.Table("Current version", info.CurrentVersion)
 	out.Table("Latest version", info.LatestVersion)
+	if info.Channel != update.ChannelStable {
+		out.Table("Channel", string(info.Channel))
+	}
 
-	if info.UpdateAvailable {
+	if info.UpdateAvailable && !info.Compatible {
+		out.Separator()
+		out.Warning("Update available, but it cannot be installed directly over this version.")
+		out.Separator()
+		showBreakingChanges(out, info)
+		if info.SteppingStone != "" {
+			fmt.Printf("Run 'core update apply --version %s' first.\n", info.SteppingStone)
+		} else {
+			fmt.Println("Run 'core update apply --force' to update anyway.")
+		}
+	} else if info.UpdateAvailable {
 		out.Separator()
 		out.Success("Update available!")
 		out.Separator()
+		showBreakingChanges(out, info)
 		fmt.Println("Run 'core update apply' to update.")
 	} else {
 		out.Separator()
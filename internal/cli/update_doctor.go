@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Tfc538/core-cli/internal/config"
+	"github.com/Tfc538/core-cli/internal/engine/update"
+	"github.com/spf13/cobra"
+)
+
+// NewUpdateDoctorCmd creates the `core update doctor` command.
+func NewUpdateDoctorCmd() *cobra.Command {
+	var jsonOutput bool
+
+	doctorCmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check whether CORE CLI can update itself",
+		Long: `Check the installed binary for problems that would make 'core update apply'
+fail: missing write permission on the binary or its directory, a read-only
+mount, too little disk space, or a symlinked binary. Binaries installed by a
+package manager (Homebrew, apt, Nix, Scoop, Snap) are reported too, since they
+should be updated through it instead.
+
+Each problem comes with a suggested fix. The same checks run automatically
+before 'core update apply'.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runUpdateDoctor(jsonOutput)
+		},
+	}
+
+	doctorCmd.Flags().BoolVar(&jsonOutput, "json", false, "Output as JSON")
+
+	return doctorCmd
+}
+
+// runUpdateDoctor runs the update preflight against the running binary.
+func runUpdateDoctor(jsonOutput bool) error {
+	out := NewOutputHelper()
+
+	binaryPath, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to determine current binary path: %w", err)
+	}
+
+	// Without a state directory downloads go to the temp directory
+	stateDir, _ := config.StateDir()
+
+	report := update.Preflight(update.PreflightConfig{
+		TargetPath: binaryPath,
+		StateDir:   stateDir,
+	})
+
+	if jsonOutput {
+		if err := outputJSON(report); err != nil {
+			return err
+		}
+		return report.Err()
+	}
+
+	out.Table("Binary", report.TargetPath)
+	if report.ResolvedPath != report.TargetPath {
+		out.Table("Resolves to", report.ResolvedPath)
+	}
+	if report.PackageManager != "" {
+		out.Table("Installed by", report.PackageManager)
+	}
+	out.Separator()
+
+	for _, check := range report.Checks {
+		printPreflightCheck(out, check)
+	}
+
+	out.Separator()
+	if err := report.Err(); err != nil {
+		out.Error("CORE CLI cannot update itself until the problems above are fixed.")
+		return err
+	}
+	out.Success("CORE CLI can update itself.")
+	return nil
+}
+
+// printPreflightProblems prints the checks that warned or failed.
+func printPreflightProblems(out *OutputHelper, report update.PreflightReport) {
+	for _, check := range report.Checks {
+		if check.Status == update.CheckWarn || check.Status == update.CheckFail {
+			printPreflightCheck(out, check)
+		}
+	}
+}
+
+// printPreflightCheck prints one check with its remedy.
+func printPreflightCheck(out *OutputHelper, check update.PreflightCheck) {
+	switch check.Status {
+	case update.CheckOK:
+		out.Success(check.Detail)
+	case update.CheckWarn:
+		out.Warning(check.Detail)
+	case update.CheckFail:
+		out.Error(check.Detail)
+	default:
+		out.Info(fmt.Sprintf("%s (skipped)", check.Detail))
+	}
+
+	if check.Remedy != "" {
+		out.Info("   → " + check.Remedy)
+	}
+}
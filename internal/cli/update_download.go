@@ -0,0 +1,151 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Tfc538/core-cli/internal/config"
+	"github.com/Tfc538/core-cli/internal/engine/update"
+	"github.com/spf13/cobra"
+)
+
+// updateDownloadOptions holds the flags of `core update download`.
+type updateDownloadOptions struct {
+	version  string
+	platform string
+	output   string
+	channel  string
+	refresh  bool
+}
+
+// NewUpdateDownloadCmd creates the `core update download` command.
+func NewUpdateDownloadCmd() *cobra.Command {
+	var opts updateDownloadOptions
+
+	downloadCmd := &cobra.Command{
+		Use:   "download",
+		Short: "Download a release into an offline update bundle",
+		Long: `Download a CORE CLI release with its checksums and signature into a
+directory that can be copied to machines without internet access.
+
+Install it there with:
+
+  core update apply --from-file <dir>
+
+The bundle is verified before it is written, and again when it is applied.`,
+		Example: `  core update download --version 1.4.0 --platform linux/arm64 -o bundle/`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runUpdateDownload(cmd.Context(), opts)
+		},
+	}
+
+	downloadCmd.Flags().StringVar(&opts.version, "version", "", "Release to download (default: the latest)")
+	downloadCmd.Flags().StringVar(&opts.platform, "platform", "", "Target platform as os/arch, e.g. linux/arm64 or linux/amd64-musl (default: this system)")
+	downloadCmd.Flags().StringVarP(&opts.output, "output", "o", "", "Bundle directory (default: core-<version>-<os>-<arch>)")
+	downloadCmd.Flags().StringVar(&opts.channel, "channel", "", "Release channel to pick the latest release from (stable, beta, nightly)")
+	downloadCmd.Flags().BoolVar(&opts.refresh, "refresh", false, "Ignore the cached update check and query the server")
+
+	return downloadCmd
+}
+
+// runUpdateDownload resolves the release for the target platform and writes
+// the bundle.
+func runUpdateDownload(ctx context.Context, opts updateDownloadOptions) error {
+	out := NewOutputHelper()
+
+	cfg, err := config.LoadUpdate()
+	if err != nil {
+		return fmt.Errorf("failed to load update config: %w", err)
+	}
+
+	channel, err := resolveChannel(opts.channel, cfg)
+	if err != nil {
+		return err
+	}
+
+	platform := update.CurrentPlatform()
+	if opts.platform != "" {
+		if platform, err = update.ParsePlatform(opts.platform); err != nil {
+			return err
+		}
+	}
+
+	settings, err := loadHTTPSettings()
+	if err != nil {
+		return err
+	}
+
+	checkerConfig := updateCheckerConfig(channel, cfg, settings, opts.refresh)
+	checkerConfig.Platform = platform
+	checker := update.NewChecker(checkerConfig)
+
+	var info *update.UpdateInfo
+	if opts.version != "" {
+		info, err = checker.CheckVersionContext(ctx, opts.version)
+	} else {
+		info, err = checker.CheckContext(ctx)
+	}
+	if err != nil {
+		out.Error("Check failed: " + describeCheckError(err, cfg))
+		return fmt.Errorf("failed to resolve release: %w", err)
+	}
+	if info.DownloadURL == "" {
+		return fmt.Errorf("v%s has no release asset for %s", info.LatestVersion, platform)
+	}
+
+	dir := opts.output
+	if dir == "" {
+		dir = fmt.Sprintf("core-%s-%s", info.LatestVersion, strings.ReplaceAll(platform.String(), "/", "-"))
+	}
+
+	stateDir, _ := config.StateDir()
+
+	// Verified like an update; nothing is installed, so there is no target
+	updater := update.NewUpdater(applyUpdaterConfig(info, "", stateDir, cfg, settings, false))
+
+	updater.SetProgressCallback(func(progress update.UpdateProgress) {
+		switch progress.Stage {
+		case update.StageDownloading:
+			if progress.BytesTotal > 0 {
+				fmt.Printf("⬇  Downloading %s from %s... %d%%\r", info.AssetName, progress.Mirror, progress.Percent)
+			}
+		case update.StageRetrying:
+			fmt.Println()
+			out.Warning(fmt.Sprintf("Download interrupted (%v), retrying (attempt %d)", progress.Error, progress.Attempt))
+		case update.StageFallback:
+			fmt.Println()
+			out.Warning(fmt.Sprintf("Download failed (%v), trying mirror %s", progress.Error, progress.Mirror))
+		case update.StageVerifying:
+			fmt.Println()
+			out.Progress("Verifying checksum")
+		}
+	})
+
+	bundle, err := updater.DownloadBundle(ctx, dir, info, platform)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return fmt.Errorf("download cancelled")
+		}
+		if reportUpdateLocked(out, err) {
+			return err
+		}
+		out.Error(fmt.Sprintf("Download failed: %v", err))
+		return fmt.Errorf("failed to download bundle: %w", err)
+	}
+
+	out.Success(fmt.Sprintf("Bundled CORE CLI v%s for %s", bundle.Version, bundle.Platform))
+	out.Table("Directory", bundle.Dir())
+	out.Table("Asset", bundle.Asset)
+	if bundle.Checksums != "" {
+		out.Table("Checksums", bundle.Checksums)
+	}
+	if bundle.Signature != "" || bundle.ChecksumSignature != "" {
+		out.Table("Signature", strings.TrimSpace(bundle.Signature+" "+bundle.ChecksumSignature))
+	}
+	out.Separator()
+	fmt.Printf("Install with: core update apply --from-file %s\n", dir)
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"time"
+
+	"github.com/Tfc538/core-cli/internal/engine/update"
+)
+
+// updateEvent is a progress line of `core update apply --json`, one per
+// UpdateProgress reported by the updater.
+type updateEvent struct {
+	Type       string       `json:"type"` // Always "progress"
+	Time       time.Time    `json:"time"`
+	Stage      update.Stage `json:"stage"`
+	Percent    int          `json:"percent"`
+	BytesDone  int64        `json:"bytes_done"`
+	BytesTotal int64        `json:"bytes_total"`
+	Mirror     string       `json:"mirror,omitempty"`
+	Attempt    int          `json:"attempt,omitempty"`
+	Error      string       `json:"error,omitempty"`
+	ErrorCode  string       `json:"error_code,omitempty"`
+}
+
+// Result statuses of `core update apply --json`.
+const (
+	applyUpdated    = "updated"
+	applyDowngraded = "downgraded"
+	applyUpToDate   = "up_to_date"
+	applyFailed     = "failed"
+	applyPlanned    = "planned"
+)
+
+// updateResult is the last line of `core update apply --json`.
+type updateResult struct {
+	Type       string    `json:"type"` // Always "result"
+	Time       time.Time `json:"time"`
+	Status     string    `json:"status"`
+	OldVersion string    `json:"old_version"`
+	NewVersion string    `json:"new_version,omitempty"`
+	BackupPath string    `json:"backup_path,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	ErrorCode  string    `json:"error_code,omitempty"`
+}
+
+// writeUpdateEvent writes one progress event as a JSON line on stdout.
+func writeUpdateEvent(progress update.UpdateProgress) {
+	event := updateEvent{
+		Type:       "progress",
+		Time:       progress.Time.UTC(),
+		Stage:      progress.Stage,
+		Percent:    progress.Percent,
+		BytesDone:  progress.BytesDone,
+		BytesTotal: progress.BytesTotal,
+		Mirror:     progress.Mirror,
+		Attempt:    progress.Attempt,
+		ErrorCode:  update.ErrorCode(progress.Error),
+	}
+	if progress.Error != nil {
+		event.Error = progress.Error.Error()
+	}
+	writeJSONLine(event)
+}
+
+// writeUpdateResult completes result from err and writes it as the final
+// JSON line. A code already set by the caller is kept.
+func writeUpdateResult(result updateResult, err error) {
+	result.Type = "result"
+	result.Time = time.Now().UTC()
+	if err != nil {
+		result.Status = applyFailed
+		result.Error = err.Error()
+		if result.ErrorCode == "" {
+			result.ErrorCode = update.ErrorCode(err)
+		}
+	}
+	writeJSONLine(result)
+}
+
+// writeJSONLine writes v as a single line of JSON on stdout.
+func writeJSONLine(v interface{}) {
+	// A consumer that went away cannot be told either
+	_ = json.NewEncoder(os.Stdout).Encode(v)
+}
+
+// newJSONOutputHelper returns an output helper for JSON modes: messages are
+// dropped so stdout only carries JSON, and errors still go to stderr.
+func newJSONOutputHelper() *OutputHelper {
+	return &OutputHelper{
+		out: io.Discard,
+		err: os.Stderr,
+	}
+}
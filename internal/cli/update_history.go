@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Tfc538/core-cli/internal/config"
+	"github.com/Tfc538/core-cli/internal/engine/update"
+	"github.com/spf13/cobra"
+)
+
+// NewUpdateHistoryCmd creates the `core update history` command.
+func NewUpdateHistoryCmd() *cobra.Command {
+	var jsonOutput bool
+
+	historyCmd := &cobra.Command{
+		Use:   "history",
+		Short: "List previous CORE CLI versions available for rollback",
+		Long:  "List the binaries retained by earlier updates, newest first.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runUpdateHistory(jsonOutput)
+		},
+	}
+
+	historyCmd.Flags().BoolVar(&jsonOutput, "json", false, "Output as JSON")
+
+	return historyCmd
+}
+
+// runUpdateHistory prints the retained binaries.
+func runUpdateHistory(jsonOutput bool) error {
+	cfg, err := config.LoadUpdate()
+	if err != nil {
+		return fmt.Errorf("failed to load update config: %w", err)
+	}
+
+	history, err := newUpdateHistory(cfg)
+	if err != nil {
+		return err
+	}
+
+	entries, err := history.List()
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		if entries == nil {
+			entries = []update.BackupEntry{}
+		}
+		return outputJSON(entries)
+	}
+
+	out := NewOutputHelper()
+	if len(entries) == 0 {
+		out.Info("No previous versions retained.")
+		return nil
+	}
+
+	for _, entry := range entries {
+		installed := "unknown"
+		if !entry.InstalledAt.IsZero() {
+			installed = entry.InstalledAt.Local().Format(time.RFC3339)
+		}
+		out.Table("v"+entry.Version, fmt.Sprintf("commit %s, installed %s", entry.Commit, installed))
+	}
+
+	out.Separator()
+	out.Info("Run 'core update rollback --to VERSION' to restore one.")
+	return nil
+}
@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Tfc538/core-cli/internal/config"
+	"github.com/Tfc538/core-cli/internal/engine/update"
+)
+
+// updatePlan is the plan line of `core update apply --dry-run --json`,
+// written before the result.
+type updatePlan struct {
+	Type           string           `json:"type"` // Always "plan"
+	Time           time.Time        `json:"time"`
+	Source         string           `json:"source"`
+	Channel        update.Channel   `json:"channel"`
+	CurrentVersion string           `json:"current_version"`
+	NewVersion     string           `json:"new_version"`
+	Direction      update.Direction `json:"direction"`
+	*update.UpdatePlan
+	Preflight update.PreflightReport `json:"preflight"`
+}
+
+// releaseSourceName describes where releases are read from, e.g.
+// "github Tfc538/core-cli" or "manifest https://example.com/releases.json".
+func releaseSourceName(cfg config.UpdateConfig) string {
+	source, sourceURL := cfg.ReleaseSource()
+	if source == "" {
+		source = string(update.SourceGitHub)
+	}
+	if source == string(update.SourceManifest) {
+		return source + " " + sourceURL
+	}
+
+	owner, repo := cfg.Repo()
+	name := fmt.Sprintf("%s %s/%s", source, owner, repo)
+	if sourceURL != "" {
+		name += " (" + sourceURL + ")"
+	}
+	return name
+}
+
+// runUpdatePlan resolves what the update would do and reports it, in the
+// form selected by opts. It fails if the real update would be refused.
+func runUpdatePlan(ctx context.Context, out *OutputHelper, opts updateApplyOptions, source string,
+	info *update.UpdateInfo, preflight update.PreflightReport, updater *update.Updater) error {
+	plan, err := updater.Plan(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to plan update: %w", err)
+	}
+
+	if opts.jsonOutput {
+		writeJSONLine(updatePlan{
+			Type:           "plan",
+			Time:           time.Now().UTC(),
+			Source:         source,
+			Channel:        info.Channel,
+			CurrentVersion: info.CurrentVersion,
+			NewVersion:     info.LatestVersion,
+			Direction:      info.Direction,
+			UpdatePlan:     plan,
+			Preflight:      preflight,
+		})
+	} else {
+		printUpdatePlan(out, source, info, plan, preflight)
+	}
+
+	// The real update would stop at the first of these
+	var errs []error
+	if !opts.skipPreflight {
+		errs = append(errs, preflight.Err())
+	}
+	errs = append(errs, plan.Err())
+	if err := errors.Join(errs...); err != nil {
+		out.Error("The update would fail; nothing was changed.")
+		return fmt.Errorf("update would fail: %w", err)
+	}
+
+	out.Success("Nothing was changed. Run without --dry-run to update.")
+	return nil
+}
+
+// printUpdatePlan prints the plan of `core update apply --dry-run`.
+func printUpdatePlan(out *OutputHelper, source string, info *update.UpdateInfo, plan *update.UpdatePlan,
+	preflight update.PreflightReport) {
+	out.Heading("Update Plan")
+	out.Table("Source", source)
+	out.Table("Current version", info.CurrentVersion)
+	out.Table("Target version", info.LatestVersion)
+	if info.Channel != update.ChannelStable {
+		out.Table("Channel", string(info.Channel))
+	}
+	out.Table("Asset", plan.AssetName)
+	for i, mirror := range plan.Mirrors {
+		label := ""
+		if i == 0 {
+			label = "Download from"
+		}
+		out.Table(label, mirror)
+	}
+	out.Table("Target location", plan.TargetPath)
+	if plan.BackupPath != "" {
+		out.Table("Backup location", plan.BackupPath)
+	} else {
+		out.Table("Backup location", "none, the current binary is not kept")
+	}
+	if plan.SelfTest {
+		out.Table("Self-test", fmt.Sprintf("the installed binary must report v%s", info.LatestVersion))
+	}
+
+	out.Heading("Verification")
+	printPreflightCheck(out, plan.Checksum)
+	printPreflightCheck(out, plan.Signature)
+
+	out.Heading("Preflight")
+	for _, check := range preflight.Checks {
+		printPreflightCheck(out, check)
+	}
+	out.Separator()
+}
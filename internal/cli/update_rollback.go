@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/Tfc538/core-cli/internal/config"
+	"github.com/Tfc538/core-cli/internal/engine/update"
+	"github.com/Tfc538/core-cli/internal/version"
+	"github.com/spf13/cobra"
+)
+
+// NewUpdateRollbackCmd creates the `core update rollback` command.
+func NewUpdateRollbackCmd() *cobra.Command {
+	var (
+		skipConfirm bool
+		toVersion   string
+	)
+
+	rollbackCmd := &cobra.Command{
+		Use:   "rollback",
+		Short: "Restore a previously installed CORE CLI version",
+		Long: `Restore a binary retained by an earlier update, replacing the current one.
+
+Without --to, the most recently replaced version is restored. The binary being
+replaced is retained in turn, so a rollback can be undone the same way.
+Run 'core update history' to list retained versions.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runUpdateRollback(cmd.Context(), toVersion, skipConfirm)
+		},
+	}
+
+	rollbackCmd.Flags().StringVar(&toVersion, "to", "", "Version to restore (default: most recent)")
+	rollbackCmd.Flags().BoolVar(&skipConfirm, "yes", false, "Skip confirmation prompt")
+
+	return rollbackCmd
+}
+
+// runUpdateRollback restores a retained binary.
+func runUpdateRollback(ctx context.Context, toVersion string, skipConfirm bool) error {
+	out := NewOutputHelper()
+
+	cfg, err := config.LoadUpdate()
+	if err != nil {
+		return fmt.Errorf("failed to load update config: %w", err)
+	}
+
+	history, err := newUpdateHistory(cfg)
+	if err != nil {
+		return err
+	}
+
+	entry, err := history.Find(update.NormalizeVersion(toVersion))
+	if err != nil {
+		if errors.Is(err, update.ErrNoBackup) {
+			out.Error("No previous version available to roll back to.")
+		}
+		return err
+	}
+
+	binaryPath, err := os.Executable()
+	if err != nil {
+		out.Error(fmt.Sprintf("Failed to locate binary: %v", err))
+		return fmt.Errorf("failed to determine current binary path: %w", err)
+	}
+
+	if !skipConfirm {
+		out.Heading("Rollback")
+		out.Table("Current version", version.Version)
+		out.Table("Restore version", entry.Version)
+		out.Table("Target location", binaryPath)
+		out.Separator()
+
+		response := promptUser(ctx, "Continue with rollback? [y/N]: ")
+		if response != "y" && response != "Y" {
+			out.Info("Rollback cancelled.")
+			return nil
+		}
+	}
+
+	restored, err := history.Restore(entry.Version, binaryPath, update.BackupEntry{
+		Version: version.Version,
+		Commit:  version.GitCommit,
+	})
+	if err != nil {
+		if reportUpdateLocked(out, err) {
+			return err
+		}
+		out.Error(fmt.Sprintf("Rollback failed: %v", err))
+		return fmt.Errorf("rollback failed: %w", err)
+	}
+
+	out.Success(fmt.Sprintf("CORE CLI rolled back to v%s", restored.Version))
+	return nil
+}
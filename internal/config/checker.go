@@ -0,0 +1,60 @@
+package config
+
+import (
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/Tfc538/core-cli/internal/engine/update"
+	"github.com/Tfc538/core-cli/internal/version"
+)
+
+// CheckerConfig returns the update checker configuration shared by the CLI
+// and the TUI: the release source, tokens and check cache from this
+// configuration and the environment, with the given channel and HTTP
+// settings from network.json.
+func (c UpdateConfig) CheckerConfig(channel update.Channel, transport http.RoundTripper, timeout time.Duration) update.CheckerConfig {
+	// Without a cache directory every check simply goes to the network
+	cacheDir, _ := CacheDir()
+	source, sourceURL := c.ReleaseSource()
+	owner, repo := c.Repo()
+
+	return update.CheckerConfig{
+		APIBaseURL:            os.Getenv("CORE_UPDATE_API_BASE"),
+		GitHubAPIBaseURL:      os.Getenv("CORE_GITHUB_API_BASE"),
+		GitHubOwner:           owner,
+		GitHubRepo:            repo,
+		CurrentVersion:        version.Version,
+		GitHubToken:           GitHubToken(),
+		Channel:               channel,
+		SourceType:            update.SourceType(source),
+		SourceURL:             sourceURL,
+		SourceToken:           SourceToken(),
+		AllowInsecureManifest: c.AllowInsecureManifest,
+		Mirrors:               c.MirrorURLs(),
+		CacheDir:              cacheDir,
+		CacheTTL:              c.CheckCacheTTL(),
+		Transport:             transport,
+		Timeout:               timeout,
+		AssetTemplates:        c.AssetTemplates,
+		ChecksumTemplates:     c.ChecksumTemplates,
+	}
+}
+
+// GitHubToken returns the token for GitHub requests from CORE_GITHUB_TOKEN,
+// GH_TOKEN or GITHUB_TOKEN, in that order.
+func GitHubToken() string {
+	if token := os.Getenv("CORE_GITHUB_TOKEN"); token != "" {
+		return token
+	}
+	if token := os.Getenv("GH_TOKEN"); token != "" {
+		return token
+	}
+	return os.Getenv("GITHUB_TOKEN")
+}
+
+// SourceToken returns the token for GitLab and Gitea sources from
+// CORE_UPDATE_TOKEN.
+func SourceToken() string {
+	return os.Getenv("CORE_UPDATE_TOKEN")
+}
@@ -0,0 +1,137 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Tfc538/core-cli/internal/network"
+)
+
+const networkConfigFile = "network.json"
+
+// NetworkConfig holds the settings for outgoing HTTP connections, read from
+// network.json in the config directory:
+//
+//	{
+//	  "ca_bundle": "/etc/ssl/corp-root.pem",
+//	  "client_cert": "~/.config/core/client.pem",
+//	  "client_key": "~/.config/core/client.key",
+//	  "proxy": "http://proxy.corp:3128",
+//	  "no_proxy": ["corp.internal"],
+//	  "proxies": {"mirror.corp.internal": "direct"},
+//	  "connect_timeout": "10s"
+//	}
+type NetworkConfig struct {
+	CABundle   string `json:"ca_bundle,omitempty"`
+	ClientCert string `json:"client_cert,omitempty"`
+	ClientKey  string `json:"client_key,omitempty"`
+	// Proxy applies to every host not listed in NoProxy or Proxies. Without
+	// it the standard proxy environment variables are used.
+	Proxy   string            `json:"proxy,omitempty"`
+	NoProxy []string          `json:"no_proxy,omitempty"`
+	Proxies map[string]string `json:"proxies,omitempty"` // host → proxy URL or "direct"
+
+	// Timeouts are Go durations such as "15s".
+	ConnectTimeout        string `json:"connect_timeout,omitempty"`
+	TLSHandshakeTimeout   string `json:"tls_handshake_timeout,omitempty"`
+	ResponseHeaderTimeout string `json:"response_header_timeout,omitempty"`
+	CheckTimeout          string `json:"check_timeout,omitempty"`    // Whole update check request
+	DownloadTimeout       string `json:"download_timeout,omitempty"` // Whole binary download
+}
+
+// Options converts the configuration into transport options. CORE_CA_BUNDLE,
+// CORE_CLIENT_CERT, CORE_CLIENT_KEY and CORE_PROXY override the persisted
+// values.
+func (c NetworkConfig) Options() (network.Options, error) {
+	opts := network.Options{
+		CABundle:    expandHome(envOr("CORE_CA_BUNDLE", c.CABundle)),
+		ClientCert:  expandHome(envOr("CORE_CLIENT_CERT", c.ClientCert)),
+		ClientKey:   expandHome(envOr("CORE_CLIENT_KEY", c.ClientKey)),
+		Proxy:       envOr("CORE_PROXY", c.Proxy),
+		NoProxy:     c.NoProxy,
+		HostProxies: c.Proxies,
+	}
+
+	var err error
+	if opts.DialTimeout, err = parseTimeout("connect_timeout", c.ConnectTimeout); err != nil {
+		return network.Options{}, err
+	}
+	if opts.TLSHandshakeTimeout, err = parseTimeout("tls_handshake_timeout", c.TLSHandshakeTimeout); err != nil {
+		return network.Options{}, err
+	}
+	if opts.ResponseHeaderTimeout, err = parseTimeout("response_header_timeout", c.ResponseHeaderTimeout); err != nil {
+		return network.Options{}, err
+	}
+
+	return opts, nil
+}
+
+// Timeouts returns the request timeouts for update checks and downloads.
+// Zero leaves the engine default in place.
+func (c NetworkConfig) Timeouts() (check, download time.Duration, err error) {
+	if check, err = parseTimeout("check_timeout", c.CheckTimeout); err != nil {
+		return 0, 0, err
+	}
+	if download, err = parseTimeout("download_timeout", c.DownloadTimeout); err != nil {
+		return 0, 0, err
+	}
+	return check, download, nil
+}
+
+// LoadNetwork reads the network configuration. A missing file yields the
+// defaults.
+func LoadNetwork() (NetworkConfig, error) {
+	var cfg NetworkConfig
+
+	dir, err := ConfigDir()
+	if err != nil {
+		return NetworkConfig{}, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, networkConfigFile))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return NetworkConfig{}, fmt.Errorf("failed to read network config: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return NetworkConfig{}, fmt.Errorf("invalid network config: %w", err)
+		}
+	}
+
+	return cfg, nil
+}
+
+func parseTimeout(name, value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid %s %q in network config", name, value)
+	}
+	return d, nil
+}
+
+func envOr(key, fallback string) string {
+	if env := os.Getenv(key); env != "" {
+		return env
+	}
+	return fallback
+}
+
+// expandHome resolves a leading ~/ to the user's home directory.
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[2:])
+}
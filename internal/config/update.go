@@ -0,0 +1,221 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const (
+	updateConfigFile   = "update.json"
+	defaultKeepBackups = 3
+	defaultCheckTTL    = time.Hour
+	defaultRepository  = "Tfc538/core-cli"
+)
+
+// UpdateConfig holds persisted update preferences for the CLI.
+type UpdateConfig struct {
+	Channel     string `json:"channel,omitempty"`
+	KeepBackups int    `json:"keep_backups,omitempty"`
+	// CheckTTL is how long a cached update check is reused without asking
+	// the server, as a Go duration such as "30m". "0s" always revalidates.
+	CheckTTL string `json:"check_ttl,omitempty"`
+	// Source selects the release host: github (default), gitlab, gitea or
+	// manifest. SourceURL is its API root, or the manifest URL.
+	Source     string `json:"source,omitempty"`
+	SourceURL  string `json:"source_url,omitempty"`
+	Repository string `json:"repository,omitempty"` // owner/name on the release host
+	// AllowInsecureManifest accepts a source_url manifest served over plain
+	// http://. Its checksums are then unprotected in transit.
+	AllowInsecureManifest bool `json:"allow_insecure_manifest,omitempty"`
+	// Mirrors are internal base URLs serving release assets as
+	// <mirror>/v<version>/<asset>.
+	Mirrors []string `json:"mirrors,omitempty"`
+	// AssetTemplates name the release binaries, as Go templates over
+	// .Binary, .Version, .OS, .Arch, .Libc and .Ext. ChecksumTemplates name
+	// the checksum file. Both default to the CORE CLI release layout.
+	AssetTemplates    []string `json:"asset_templates,omitempty"`
+	ChecksumTemplates []string `json:"checksum_templates,omitempty"`
+}
+
+// Backups returns how many previous binaries to retain for rollback.
+func (c UpdateConfig) Backups() int {
+	if c.KeepBackups <= 0 {
+		return defaultKeepBackups
+	}
+	return c.KeepBackups
+}
+
+// CheckCacheTTL returns how long cached update checks stay fresh. Missing or
+// invalid values fall back to one hour.
+func (c UpdateConfig) CheckCacheTTL() time.Duration {
+	if c.CheckTTL == "" {
+		return defaultCheckTTL
+	}
+	ttl, err := time.ParseDuration(c.CheckTTL)
+	if err != nil || ttl < 0 {
+		return defaultCheckTTL
+	}
+	return ttl
+}
+
+// ReleaseSource returns the release host and its URL. CORE_UPDATE_SOURCE and
+// CORE_UPDATE_SOURCE_URL override the persisted values, and
+// CORE_UPDATE_MANIFEST_URL alone selects a self-hosted release manifest.
+func (c UpdateConfig) ReleaseSource() (source, sourceURL string) {
+	source, sourceURL = c.Source, c.SourceURL
+	if env := os.Getenv("CORE_UPDATE_MANIFEST_URL"); env != "" {
+		return "manifest", env
+	}
+	if env := os.Getenv("CORE_UPDATE_SOURCE"); env != "" {
+		source = env
+	}
+	if env := os.Getenv("CORE_UPDATE_SOURCE_URL"); env != "" {
+		sourceURL = env
+	}
+	return source, sourceURL
+}
+
+// Repo returns the owner and name of the repository releases are read
+// from. GitLab subgroups are kept in the owner.
+func (c UpdateConfig) Repo() (owner, name string) {
+	repository := strings.Trim(c.Repository, "/")
+	if env := os.Getenv("CORE_UPDATE_REPOSITORY"); env != "" {
+		repository = strings.Trim(env, "/")
+	}
+
+	i := strings.LastIndex(repository, "/")
+	if i <= 0 || i == len(repository)-1 {
+		repository = defaultRepository
+		i = strings.LastIndex(repository, "/")
+	}
+	return repository[:i], repository[i+1:]
+}
+
+// MirrorURLs returns the configured download mirrors. CORE_UPDATE_MIRRORS, a
+// comma-separated list, overrides the persisted value.
+func (c UpdateConfig) MirrorURLs() []string {
+	env := os.Getenv("CORE_UPDATE_MIRRORS")
+	if env == "" {
+		return c.Mirrors
+	}
+
+	var mirrors []string
+	for _, mirror := range strings.Split(env, ",") {
+		if mirror = strings.TrimSpace(mirror); mirror != "" {
+			mirrors = append(mirrors, mirror)
+		}
+	}
+	return mirrors
+}
+
+// ConfigDir returns the directory holding CORE CLI configuration.
+// CORE_CONFIG_DIR overrides the platform default (e.g. ~/.config/core).
+func ConfigDir() (string, error) {
+	if dir := os.Getenv("CORE_CONFIG_DIR"); dir != "" {
+		return dir, nil
+	}
+
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate user config directory: %w", err)
+	}
+
+	return filepath.Join(base, "core"), nil
+}
+
+// CacheDir returns the directory holding disposable data such as cached
+// update checks. CORE_CACHE_DIR overrides the platform default
+// (e.g. ~/.cache/core).
+func CacheDir() (string, error) {
+	if dir := os.Getenv("CORE_CACHE_DIR"); dir != "" {
+		return dir, nil
+	}
+
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate user cache directory: %w", err)
+	}
+
+	return filepath.Join(base, "core"), nil
+}
+
+// StateDir returns the directory holding update state such as retained
+// binaries. CORE_STATE_DIR overrides the default of $XDG_STATE_HOME/core
+// (~/.local/state/core) on Unix and the user config directory elsewhere.
+func StateDir() (string, error) {
+	if dir := os.Getenv("CORE_STATE_DIR"); dir != "" {
+		return dir, nil
+	}
+
+	switch runtime.GOOS {
+	case "windows", "darwin", "ios", "plan9":
+		base, err := os.UserConfigDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to locate user config directory: %w", err)
+		}
+		return filepath.Join(base, "core", "state"), nil
+	}
+
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "core"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate home directory: %w", err)
+	}
+
+	return filepath.Join(home, ".local", "state", "core"), nil
+}
+
+// LoadUpdate reads the persisted update configuration. A missing file yields
+// the defaults.
+func LoadUpdate() (UpdateConfig, error) {
+	var cfg UpdateConfig
+
+	dir, err := ConfigDir()
+	if err != nil {
+		return UpdateConfig{}, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, updateConfigFile))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return UpdateConfig{}, fmt.Errorf("failed to read update config: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return UpdateConfig{}, fmt.Errorf("invalid update config: %w", err)
+		}
+	}
+
+	return cfg, nil
+}
+
+// SaveUpdate persists the update configuration.
+func SaveUpdate(cfg UpdateConfig) error {
+	dir, err := ConfigDir()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode update config: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, updateConfigFile), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write update config: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package update
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrRateLimited is returned when the release host throttles requests.
+	// The *APIError carries when the limit resets, if the host says so.
+	ErrRateLimited = errors.New("rate limited")
+	// ErrUnauthorized is returned when the host rejects the token, or
+	// requires one.
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrRepoNotFound is returned when the repository or release does not
+	// exist. Private repositories look the same without a token.
+	ErrRepoNotFound = errors.New("repository not found")
+	// ErrReleaseNotFound is returned by ReleaseByTag when the repository is
+	// readable but has no release under the tag, e.g. while it is still
+	// being published.
+	ErrReleaseNotFound = errors.New("release not found")
+	// ErrNetwork is returned when the host cannot be reached at all.
+	ErrNetwork = errors.New("network error")
+)
+
+// maxAPIMessage bounds how much of an unparseable error body is reported.
+const maxAPIMessage = 200
+
+// APIError is a failed request to a release host API. Err classifies it as
+// one of ErrRateLimited, ErrUnauthorized, ErrRepoNotFound,
+// ErrReleaseNotFound or ErrNetwork, and is nil for other failures such as
+// server errors.
+type APIError struct {
+	Host    string // API name, e.g. "GitHub"
+	Status  int    // HTTP status; 0 for network errors
+	Message string // Error message from the response body
+	// RetryAt is when a rate limit resets, from Retry-After or
+	// X-RateLimit-Reset. It is zero when the host did not say.
+	RetryAt time.Time
+	Err     error
+
+	cause error
+}
+
+// newAPIError classifies a non-200 response. GitHub signals rate limits
+// with 403 as well as 429, so 403s are told apart by their headers.
+func newAPIError(host string, status int, header http.Header, body []byte) *APIError {
+	e := &APIError{Host: host, Status: status, Message: apiMessage(body)}
+
+	rateLimited := status == http.StatusTooManyRequests ||
+		header.Get("X-RateLimit-Remaining") == "0" ||
+		header.Get("Retry-After") != "" ||
+		strings.Contains(strings.ToLower(e.Message), "rate limit")
+
+	switch {
+	case rateLimited && (status == http.StatusForbidden || status == http.StatusTooManyRequests):
+		e.Err = ErrRateLimited
+		e.RetryAt = retryAt(header, time.Now())
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		e.Err = ErrUnauthorized
+	case status == http.StatusNotFound:
+		e.Err = ErrRepoNotFound
+	}
+
+	return e
+}
+
+func (e *APIError) Error() string {
+	switch e.Err {
+	case ErrNetwork:
+		return fmt.Sprintf("failed to reach %s API: %v", e.Host, e.cause)
+	case ErrRateLimited:
+		// The body only repeats that the limit was exceeded
+		msg := fmt.Sprintf("%s API rate limit exceeded", e.Host)
+		if !e.RetryAt.IsZero() {
+			msg += " until " + e.RetryAt.Local().Format(time.TimeOnly)
+		}
+		return msg
+	}
+
+	msg := fmt.Sprintf("%s API returned %d", e.Host, e.Status)
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+func (e *APIError) Unwrap() []error {
+	var errs []error
+	for _, err := range []error{e.Err, e.cause} {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// apiMessage extracts the message of a JSON error body, as sent by GitHub,
+// Gitea and GitLab, or returns the start of the body.
+func apiMessage(body []byte) string {
+	var payload struct {
+		Message string `json:"message"`
+		Error   string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &payload); err == nil {
+		if payload.Message != "" {
+			return payload.Message
+		}
+		if payload.Error != "" {
+			return payload.Error
+		}
+	}
+
+	msg := strings.TrimSpace(string(body))
+	if len(msg) > maxAPIMessage {
+		msg = msg[:maxAPIMessage] + "…"
+	}
+	return msg
+}
+
+// retryAt returns when a rate-limited request may be retried. Retry-After
+// holds seconds or an HTTP date; X-RateLimit-Reset holds Unix seconds.
+func retryAt(header http.Header, now time.Time) time.Time {
+	if value := strings.TrimSpace(header.Get("Retry-After")); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+			return now.Add(time.Duration(seconds) * time.Second)
+		}
+		if t, err := http.ParseTime(value); err == nil {
+			return t
+		}
+	}
+
+	if value := strings.TrimSpace(header.Get("X-RateLimit-Reset")); value != "" {
+		if unix, err := strconv.ParseInt(value, 10, 64); err == nil && unix > 0 {
+			return time.Unix(unix, 0)
+		}
+	}
+
+	return time.Time{}
+}
@@ -0,0 +1,175 @@
+package update
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewAPIError(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		header  map[string]string
+		body    string
+		want    error
+		message string
+	}{
+		{
+			name:    "primary rate limit",
+			status:  http.StatusForbidden,
+			header:  map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": "1700000000"},
+			body:    `{"message":"API rate limit exceeded for 203.0.113.1."}`,
+			want:    ErrRateLimited,
+			message: "API rate limit exceeded for 203.0.113.1.",
+		},
+		{
+			name:   "secondary rate limit",
+			status: http.StatusForbidden,
+			header: map[string]string{"Retry-After": "60"},
+			body:   `{"message":"You have exceeded a secondary rate limit."}`,
+			want:   ErrRateLimited,
+		},
+		{
+			name:   "too many requests",
+			status: http.StatusTooManyRequests,
+			want:   ErrRateLimited,
+		},
+		{
+			name:    "bad credentials",
+			status:  http.StatusUnauthorized,
+			body:    `{"message":"Bad credentials"}`,
+			want:    ErrUnauthorized,
+			message: "Bad credentials",
+		},
+		{
+			name:   "forbidden",
+			status: http.StatusForbidden,
+			header: map[string]string{"X-RateLimit-Remaining": "4999"},
+			body:   `{"message":"Resource not accessible by personal access token"}`,
+			want:   ErrUnauthorized,
+		},
+		{
+			name:    "not found",
+			status:  http.StatusNotFound,
+			body:    `{"message":"404 Project Not Found"}`,
+			want:    ErrRepoNotFound,
+			message: "404 Project Not Found",
+		},
+		{
+			name:    "server error",
+			status:  http.StatusBadGateway,
+			body:    "<html>bad gateway</html>",
+			message: "<html>bad gateway</html>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			for key, value := range tt.header {
+				header.Set(key, value)
+			}
+
+			err := newAPIError("GitHub", tt.status, header, []byte(tt.body))
+			if err.Err != tt.want {
+				t.Errorf("Err = %v, want %v", err.Err, tt.want)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("errors.Is(%v, %v) = false", err, tt.want)
+			}
+			if tt.message != "" && err.Message != tt.message {
+				t.Errorf("Message = %q, want %q", err.Message, tt.message)
+			}
+			if tt.want == ErrRateLimited && tt.header != nil && err.RetryAt.IsZero() {
+				t.Error("Expected the reset time of the rate limit")
+			}
+		})
+	}
+}
+
+func TestRetryAt(t *testing.T) {
+	now := time.Date(2025, 6, 1, 14, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		header map[string]string
+		want   time.Time
+	}{
+		{"retry after seconds", map[string]string{"Retry-After": "90"}, now.Add(90 * time.Second)},
+		{"retry after date", map[string]string{"Retry-After": "Sun, 01 Jun 2025 14:05:00 GMT"}, now.Add(5 * time.Minute)},
+		{"reset", map[string]string{"X-RateLimit-Reset": strconv.FormatInt(now.Add(time.Hour).Unix(), 10)}, now.Add(time.Hour)},
+		{"retry after wins", map[string]string{"Retry-After": "30", "X-RateLimit-Reset": "1"}, now.Add(30 * time.Second)},
+		{"unknown", nil, time.Time{}},
+		{"invalid", map[string]string{"Retry-After": "soon"}, time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			for key, value := range tt.header {
+				header.Set(key, value)
+			}
+			if got := retryAt(header, now); !got.Equal(tt.want) {
+				t.Errorf("retryAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChecker_RateLimited(t *testing.T) {
+	reset := time.Now().Add(30 * time.Minute).Truncate(time.Second)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"message":"API rate limit exceeded"}`))
+	}))
+	defer server.Close()
+
+	checker := NewChecker(CheckerConfig{
+		APIBaseURL:       server.URL,
+		GitHubAPIBaseURL: server.URL,
+		GitHubOwner:      "test",
+		GitHubRepo:       "test",
+		CurrentVersion:   "1.0.0",
+	})
+
+	_, err := checker.Check()
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("Expected ErrRateLimited, got %v", err)
+	}
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected an *APIError, got %T", err)
+	}
+	if !apiErr.RetryAt.Equal(reset) {
+		t.Errorf("RetryAt = %v, want %v", apiErr.RetryAt, reset)
+	}
+	if !strings.Contains(err.Error(), reset.Local().Format(time.TimeOnly)) {
+		t.Errorf("Expected the reset time in %q", err.Error())
+	}
+}
+
+func TestChecker_NetworkError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	checker := NewChecker(CheckerConfig{
+		APIBaseURL:       url,
+		GitHubAPIBaseURL: url,
+		GitHubOwner:      "test",
+		GitHubRepo:       "test",
+		CurrentVersion:   "1.0.0",
+	})
+
+	if _, err := checker.Check(); !errors.Is(err, ErrNetwork) {
+		t.Errorf("Expected ErrNetwork, got %v", err)
+	}
+}
@@ -0,0 +1,227 @@
+package update
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"runtime"
+	"strings"
+)
+
+// maxBinarySize caps how much is extracted from an archive, guarding
+// against decompression bombs.
+const maxBinarySize = 512 << 20
+
+// archiveFormat identifies how a release asset is packaged.
+type archiveFormat int
+
+const (
+	formatRaw archiveFormat = iota
+	formatTarGz
+	formatZip
+)
+
+// ErrBinaryNotInArchive is returned when an archive has no CORE executable.
+var ErrBinaryNotInArchive = errors.New("archive does not contain the core binary")
+
+// detectArchive determines the asset format from its name, falling back to
+// the file's magic bytes.
+func detectArchive(name, filePath string) (archiveFormat, error) {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return formatTarGz, nil
+	case strings.HasSuffix(lower, ".zip"):
+		return formatZip, nil
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return formatRaw, fmt.Errorf("failed to open download: %w", err)
+	}
+	defer file.Close()
+
+	magic := make([]byte, 4)
+	n, _ := io.ReadFull(file, magic)
+	magic = magic[:n]
+
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		return formatTarGz, nil
+	case bytes.HasPrefix(magic, []byte("PK\x03\x04")):
+		return formatZip, nil
+	}
+
+	return formatRaw, nil
+}
+
+// extractBinary returns the path of the executable to install. Raw assets
+// are returned as-is; archives are unpacked into a new temporary file that
+// the caller must remove.
+func (u *Updater) extractBinary(filePath string) (string, error) {
+	format, err := detectArchive(u.assetName(), filePath)
+	if err != nil || format == formatRaw {
+		return filePath, err
+	}
+
+	u.emit(UpdateProgress{
+		Stage: StageExtracting,
+	})
+
+	candidates := binaryCandidates(u.assetName())
+
+	switch format {
+	case formatTarGz:
+		return extractFromTarGz(filePath, candidates)
+	default:
+		return extractFromZip(filePath, candidates)
+	}
+}
+
+// binaryCandidates lists the entry names accepted as the CORE executable:
+// core (core.exe on Windows), or the archive name without its extension.
+func binaryCandidates(assetName string) []string {
+	candidates := []string{binaryName()}
+
+	base := strings.ToLower(assetName)
+	for _, ext := range []string{".tar.gz", ".tgz", ".zip"} {
+		if strings.HasSuffix(base, ext) {
+			stripped := assetName[:len(assetName)-len(ext)]
+			candidates = append(candidates, stripped)
+			if runtime.GOOS == "windows" && !strings.HasSuffix(stripped, ".exe") {
+				candidates = append(candidates, stripped+".exe")
+			}
+			break
+		}
+	}
+
+	return candidates
+}
+
+// safeEntryName validates an archive entry path and returns its base name.
+// Absolute paths and parent-directory components are rejected outright
+// rather than skipped, since they indicate a malicious archive.
+func safeEntryName(name string) (string, error) {
+	name = strings.ReplaceAll(name, "\\", "/")
+	if name == "" || strings.HasPrefix(name, "/") || (len(name) > 1 && name[1] == ':') {
+		return "", fmt.Errorf("unsafe archive entry %q", name)
+	}
+	for _, part := range strings.Split(name, "/") {
+		if part == ".." {
+			return "", fmt.Errorf("unsafe archive entry %q", name)
+		}
+	}
+	return path.Base(name), nil
+}
+
+// matchesCandidate reports whether an entry base name is an accepted
+// executable name.
+func matchesCandidate(base string, candidates []string) bool {
+	for _, candidate := range candidates {
+		if base == candidate {
+			return true
+		}
+	}
+	return false
+}
+
+// extractFromTarGz extracts the first regular file matching candidates.
+func extractFromTarGz(archivePath string, candidates []string) (string, error) {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to read gzip archive: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return "", ErrBinaryNotInArchive
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read tar archive: %w", err)
+		}
+
+		base, err := safeEntryName(header.Name)
+		if err != nil {
+			return "", err
+		}
+
+		// Links could point anywhere; only regular files are installed
+		if header.Typeflag != tar.TypeReg || !matchesCandidate(base, candidates) {
+			continue
+		}
+
+		return writeExtracted(tr)
+	}
+}
+
+// extractFromZip extracts the first regular file matching candidates.
+func extractFromZip(archivePath string, candidates []string) (string, error) {
+	zr, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read zip archive: %w", err)
+	}
+	defer zr.Close()
+
+	for _, entry := range zr.File {
+		base, err := safeEntryName(entry.Name)
+		if err != nil {
+			return "", err
+		}
+
+		if !entry.Mode().IsRegular() || !matchesCandidate(base, candidates) {
+			continue
+		}
+
+		rc, err := entry.Open()
+		if err != nil {
+			return "", fmt.Errorf("failed to open archive entry: %w", err)
+		}
+		defer rc.Close()
+
+		return writeExtracted(rc)
+	}
+
+	return "", ErrBinaryNotInArchive
+}
+
+// writeExtracted copies an archive entry into an executable temporary file.
+func writeExtracted(r io.Reader) (string, error) {
+	out, err := os.CreateTemp("", "core-update-bin-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	outPath := out.Name()
+
+	n, err := io.Copy(out, io.LimitReader(r, maxBinarySize+1))
+	closeErr := out.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil && n > maxBinarySize {
+		err = fmt.Errorf("archive entry exceeds %d bytes", maxBinarySize)
+	}
+	if err == nil {
+		err = os.Chmod(outPath, 0755)
+	}
+	if err != nil {
+		os.Remove(outPath)
+		return "", fmt.Errorf("failed to extract binary: %w", err)
+	}
+
+	return outPath, nil
+}
@@ -0,0 +1,168 @@
+package update
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type archiveEntry struct {
+	name     string
+	content  string
+	typeflag byte
+}
+
+func writeTarGz(t *testing.T, entries []archiveEntry) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		typeflag := e.typeflag
+		if typeflag == 0 {
+			typeflag = tar.TypeReg
+		}
+		hdr := &tar.Header{Name: e.name, Mode: 0755, Size: int64(len(e.content)), Typeflag: typeflag}
+		if typeflag == tar.TypeSymlink {
+			hdr.Size = 0
+			hdr.Linkname = e.content
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatalf("failed to write tar header: %v", err)
+		}
+		if typeflag == tar.TypeReg {
+			tw.Write([]byte(e.content))
+		}
+	}
+	tw.Close()
+	gz.Close()
+
+	path := filepath.Join(t.TempDir(), "download")
+	os.WriteFile(path, buf.Bytes(), 0644)
+	return path
+}
+
+func writeZip(t *testing.T, entries []archiveEntry) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		w, err := zw.Create(e.name)
+		if err != nil {
+			t.Fatalf("failed to create zip entry: %v", err)
+		}
+		w.Write([]byte(e.content))
+	}
+	zw.Close()
+
+	path := filepath.Join(t.TempDir(), "download")
+	os.WriteFile(path, buf.Bytes(), 0644)
+	return path
+}
+
+func TestUpdater_ExtractBinary_TarGz(t *testing.T) {
+	archive := writeTarGz(t, []archiveEntry{
+		{name: "README.md", content: "docs"},
+		{name: "core-linux-amd64/" + binaryName(), content: "binary from tar"},
+	})
+
+	updater := NewUpdater(UpdaterConfig{AssetName: "core-linux-amd64.tar.gz"})
+	extracted, err := updater.extractBinary(archive)
+	if err != nil {
+		t.Fatalf("extractBinary() failed: %v", err)
+	}
+	defer os.Remove(extracted)
+
+	content, _ := os.ReadFile(extracted)
+	if string(content) != "binary from tar" {
+		t.Errorf("Extracted content mismatch: %q", content)
+	}
+
+	info, _ := os.Stat(extracted)
+	if info.Mode()&0100 == 0 {
+		t.Error("Extracted binary is not executable")
+	}
+}
+
+func TestUpdater_ExtractBinary_Zip(t *testing.T) {
+	archive := writeZip(t, []archiveEntry{
+		{name: binaryName(), content: "binary from zip"},
+	})
+
+	// Detected by magic bytes even without a telling asset name
+	updater := NewUpdater(UpdaterConfig{DownloadURL: "https://example.com/download"})
+	extracted, err := updater.extractBinary(archive)
+	if err != nil {
+		t.Fatalf("extractBinary() failed: %v", err)
+	}
+	defer os.Remove(extracted)
+
+	content, _ := os.ReadFile(extracted)
+	if string(content) != "binary from zip" {
+		t.Errorf("Extracted content mismatch: %q", content)
+	}
+}
+
+func TestUpdater_ExtractBinary_Raw(t *testing.T) {
+	raw := filepath.Join(t.TempDir(), "core-linux-amd64")
+	os.WriteFile(raw, []byte("\x7fELF raw binary"), 0755)
+
+	updater := NewUpdater(UpdaterConfig{AssetName: "core-linux-amd64"})
+	extracted, err := updater.extractBinary(raw)
+	if err != nil {
+		t.Fatalf("extractBinary() failed: %v", err)
+	}
+	if extracted != raw {
+		t.Errorf("Raw binaries should be installed as-is, got %s", extracted)
+	}
+}
+
+func TestUpdater_ExtractBinary_Rejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []archiveEntry
+		wantErr error
+	}{
+		{
+			name:    "path traversal",
+			entries: []archiveEntry{{name: "../../" + binaryName(), content: "evil"}},
+		},
+		{
+			name:    "absolute path",
+			entries: []archiveEntry{{name: "/usr/bin/" + binaryName(), content: "evil"}},
+		},
+		{
+			name:    "symlink only",
+			entries: []archiveEntry{{name: binaryName(), content: "/etc/passwd", typeflag: tar.TypeSymlink}},
+			wantErr: ErrBinaryNotInArchive,
+		},
+		{
+			name:    "missing binary",
+			entries: []archiveEntry{{name: "core-backend", content: "backend"}},
+			wantErr: ErrBinaryNotInArchive,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			archive := writeTarGz(t, tt.entries)
+			updater := NewUpdater(UpdaterConfig{AssetName: "core-linux-amd64.tar.gz"})
+
+			extracted, err := updater.extractBinary(archive)
+			if err == nil {
+				os.Remove(extracted)
+				t.Fatal("extractBinary() should fail")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("extractBinary() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,319 @@
+package update
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"text/template"
+)
+
+// ErrNoMatchingAsset is returned when a release has no binary for the
+// platform being updated.
+var ErrNoMatchingAsset = errors.New("no matching release asset")
+
+const (
+	// DefaultAssetTemplate names release binaries, e.g. core-linux-amd64,
+	// core-linux-armv7.tar.gz or core-linux-amd64-musl.
+	DefaultAssetTemplate = "{{.Binary}}-{{.OS}}-{{.Arch}}{{if .Libc}}-{{.Libc}}{{end}}{{.Ext}}"
+	defaultBinaryName    = "core"
+)
+
+// DefaultChecksumAssets are the checksum file names looked for when no
+// checksum template is configured.
+var DefaultChecksumAssets = []string{"checksums.txt", "SHA256SUMS", "sha256sums.txt"}
+
+// Platform identifies the system a release binary must run on.
+type Platform struct {
+	OS   string // GOOS
+	Arch string // GOARCH
+	// ARM is the GOARM variant ("6" or "7") of 32-bit ARM systems.
+	ARM string
+	// Libc is "musl" on musl-based Linux distributions such as Alpine.
+	Libc string
+}
+
+// CurrentPlatform describes the running system. The ARM variant is the one
+// this binary was built for.
+func CurrentPlatform() Platform {
+	p := Platform{OS: runtime.GOOS, Arch: runtime.GOARCH}
+
+	if p.Arch == "arm" {
+		if info, ok := debug.ReadBuildInfo(); ok {
+			for _, setting := range info.Settings {
+				if setting.Key == "GOARM" {
+					p.ARM = strings.SplitN(setting.Value, ",", 2)[0]
+				}
+			}
+		}
+	}
+
+	if p.OS == "linux" && isMusl() {
+		p.Libc = "musl"
+	}
+
+	return p
+}
+
+// ParsePlatform parses a platform in the form printed by String, such as
+// linux/arm64, linux/armv7 or linux/amd64-musl.
+func ParsePlatform(s string) (Platform, error) {
+	goos, arch, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok || goos == "" || arch == "" {
+		return Platform{}, fmt.Errorf("invalid platform %q: expected os/arch, e.g. linux/arm64", s)
+	}
+
+	p := Platform{OS: goos, Arch: arch}
+	if arch, libc, ok := strings.Cut(arch, "-"); ok {
+		if libc != "musl" && libc != "gnu" {
+			return Platform{}, fmt.Errorf("invalid platform %q: unknown libc %q", s, libc)
+		}
+		p.Arch = arch
+		// Plain names are glibc builds already
+		if libc == "musl" {
+			p.Libc = libc
+		}
+	}
+	if variant, ok := strings.CutPrefix(p.Arch, "armv"); ok {
+		p.Arch, p.ARM = "arm", variant
+	}
+
+	return p, nil
+}
+
+// String returns the platform as os/arch, with ARM variant and libc.
+func (p Platform) String() string {
+	s := p.OS + "/" + p.archName()
+	if p.Libc != "" {
+		s += "-" + p.Libc
+	}
+	return s
+}
+
+// archName returns the architecture as it appears in asset names.
+func (p Platform) archName() string {
+	if p.Arch == "arm" && p.ARM != "" {
+		return "armv" + p.ARM
+	}
+	return p.Arch
+}
+
+// archCandidates lists the architecture names of binaries that run on the
+// platform, best first: newer ARM variants run older ones, and macOS runs
+// universal binaries.
+func (p Platform) archCandidates() []string {
+	candidates := []string{p.archName()}
+
+	if p.Arch == "arm" {
+		if p.ARM == "7" {
+			candidates = append(candidates, "armv6")
+		}
+		if p.ARM != "" {
+			candidates = append(candidates, "arm")
+		}
+	}
+
+	if p.OS == "darwin" {
+		candidates = append(candidates, "universal", "all")
+	}
+
+	return candidates
+}
+
+// libcCandidates lists the libc suffixes to try, best first. Plain names
+// are assumed to be static or glibc builds.
+func (p Platform) libcCandidates() []string {
+	switch {
+	case p.Libc == "musl":
+		return []string{"musl", ""}
+	case p.OS == "linux":
+		return []string{"", "gnu"}
+	}
+	return []string{""}
+}
+
+// extCandidates lists asset extensions for the platform, raw binaries first.
+func (p Platform) extCandidates() []string {
+	if p.OS == "windows" {
+		return []string{".exe", ".zip", ".tar.gz"}
+	}
+	return []string{"", ".tar.gz", ".zip"}
+}
+
+// assetTemplateData is the data asset templates are rendered with.
+type assetTemplateData struct {
+	Binary  string // Binary name, "core"
+	Version string // Release version without "v"
+	OS      string
+	Arch    string // Architecture including ARM variant, e.g. armv7
+	Libc    string // "musl", "gnu" or empty
+	Ext     string // ".exe", ".tar.gz", ".zip" or empty
+}
+
+// assetNames renders the asset templates for every variant that runs on
+// the platform, in preference order.
+func (c *Checker) assetNames(version string) ([]string, error) {
+	templates := c.config.AssetTemplates
+	if len(templates) == 0 {
+		templates = []string{DefaultAssetTemplate}
+	}
+
+	p := c.platform()
+	data := assetTemplateData{Binary: c.binaryName(), Version: version, OS: p.OS}
+
+	seen := make(map[string]bool)
+	var names []string
+	for _, text := range templates {
+		tmpl, err := template.New("asset").Option("missingkey=error").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid asset template %q: %w", text, err)
+		}
+
+		for _, data.Arch = range p.archCandidates() {
+			for _, data.Libc = range p.libcCandidates() {
+				for _, data.Ext = range p.extCandidates() {
+					var buf bytes.Buffer
+					if err := tmpl.Execute(&buf, data); err != nil {
+						return nil, fmt.Errorf("invalid asset template %q: %w", text, err)
+					}
+					if name := buf.String(); !seen[name] {
+						seen[name] = true
+						names = append(names, name)
+					}
+				}
+			}
+		}
+	}
+
+	return names, nil
+}
+
+// checksumNames renders the checksum templates, or returns the default
+// checksum file names.
+func (c *Checker) checksumNames(version string) ([]string, error) {
+	if len(c.config.ChecksumTemplates) == 0 {
+		return DefaultChecksumAssets, nil
+	}
+
+	data := assetTemplateData{Binary: c.binaryName(), Version: version, OS: c.platform().OS}
+
+	var names []string
+	for _, text := range c.config.ChecksumTemplates {
+		tmpl, err := template.New("checksum").Option("missingkey=error").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid checksum template %q: %w", text, err)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("invalid checksum template %q: %w", text, err)
+		}
+		names = append(names, buf.String())
+	}
+	return names, nil
+}
+
+// selectAssets picks the binary and checksum file of a release by exact
+// name, in template preference order, falling back to the platform the
+// source declares for an asset. binary is nil without an error when
+// the release has no downloads at all.
+func (c *Checker) selectAssets(release *Release) (binary, checksum *Asset, err error) {
+	version := c.parseVersion(release.TagName)
+
+	byName := make(map[string]*Asset, len(release.Assets))
+	for i := range release.Assets {
+		byName[release.Assets[i].Name] = &release.Assets[i]
+	}
+
+	names, err := c.assetNames(version)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, name := range names {
+		if asset, ok := byName[name]; ok {
+			binary = asset
+			break
+		}
+	}
+
+	checksumNames, err := c.checksumNames(version)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, name := range checksumNames {
+		if asset, ok := byName[name]; ok {
+			checksum = asset
+			break
+		}
+	}
+
+	if binary == nil {
+		binary = c.declaredAsset(release)
+	}
+
+	if binary == nil {
+		return nil, checksum, c.noAssetError(release, names)
+	}
+	return binary, checksum, nil
+}
+
+// declaredAsset picks the asset whose declared platform runs here, for
+// sources whose asset names do not follow the templates. Architectures are
+// tried in preference order.
+func (c *Checker) declaredAsset(release *Release) *Asset {
+	p := c.platform()
+	for _, arch := range p.archCandidates() {
+		for i, asset := range release.Assets {
+			if asset.OS == p.OS && asset.Arch == arch {
+				return &release.Assets[i]
+			}
+		}
+	}
+	return nil
+}
+
+// noAssetError explains which names were looked for and what the release
+// offers instead. Releases without any downloads yet, e.g. while CI is
+// still uploading, are not an error; they just have nothing to install.
+func (c *Checker) noAssetError(release *Release, tried []string) error {
+	var available []string
+	for _, asset := range release.Assets {
+		if !strings.HasSuffix(asset.Name, signatureExt) && asset.Name != metadataAssetName {
+			available = append(available, asset.Name)
+		}
+	}
+	if len(available) == 0 {
+		return nil
+	}
+
+	if len(tried) > 4 {
+		tried = append(tried[:4:4], "…")
+	}
+
+	return fmt.Errorf("%w: %s has nothing for %s (looked for %s); available assets: %s",
+		ErrNoMatchingAsset, release.TagName, c.platform(), strings.Join(tried, ", "), strings.Join(available, ", "))
+}
+
+// platform returns the configured target platform, or the running one.
+func (c *Checker) platform() Platform {
+	if c.config.Platform.OS != "" {
+		return c.config.Platform
+	}
+	return CurrentPlatform()
+}
+
+func (c *Checker) binaryName() string {
+	if c.config.BinaryName != "" {
+		return c.config.BinaryName
+	}
+	return defaultBinaryName
+}
+
+// isMusl reports whether the system's C library is musl, which installs its
+// dynamic loader as /lib/ld-musl-<arch>.so.1.
+func isMusl() bool {
+	matches, _ := filepath.Glob("/lib/ld-musl-*.so.1")
+	return len(matches) > 0
+}
@@ -0,0 +1,201 @@
+package update
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// releaseWithAssets returns a release publishing the named assets.
+func releaseWithAssets(names ...string) *Release {
+	release := &Release{TagName: "v1.2.0"}
+	for _, name := range names {
+		release.Assets = append(release.Assets, Asset{Name: name, DownloadURL: "https://example.com/" + name})
+	}
+	return release
+}
+
+func TestChecker_SelectAssets(t *testing.T) {
+	linuxAmd64 := Platform{OS: "linux", Arch: "amd64"}
+
+	tests := []struct {
+		name         string
+		platform     Platform
+		templates    []string
+		assets       []string
+		wantBinary   string
+		wantChecksum string
+	}{
+		{
+			name:         "exact name beats longer lookalikes",
+			platform:     linuxAmd64,
+			assets:       []string{"core-linux-amd64.sha256", "core-backend-linux-amd64", "core-linux-amd64", "checksums.txt.minisig", "old-checksums.txt", "checksums.txt"},
+			wantBinary:   "core-linux-amd64",
+			wantChecksum: "checksums.txt",
+		},
+		{
+			name:       "raw binary preferred over archive",
+			platform:   linuxAmd64,
+			assets:     []string{"core-linux-amd64.tar.gz", "core-linux-amd64"},
+			wantBinary: "core-linux-amd64",
+		},
+		{
+			name:       "windows executable",
+			platform:   Platform{OS: "windows", Arch: "amd64"},
+			assets:     []string{"core-windows-amd64", "core-windows-amd64.exe"},
+			wantBinary: "core-windows-amd64.exe",
+		},
+		{
+			name:       "armv7 prefers its own build",
+			platform:   Platform{OS: "linux", Arch: "arm", ARM: "7"},
+			assets:     []string{"core-linux-armv6", "core-linux-armv7"},
+			wantBinary: "core-linux-armv7",
+		},
+		{
+			name:       "armv7 runs armv6",
+			platform:   Platform{OS: "linux", Arch: "arm", ARM: "7"},
+			assets:     []string{"core-linux-armv6", "core-linux-arm64"},
+			wantBinary: "core-linux-armv6",
+		},
+		{
+			name:     "armv6 cannot run armv7",
+			platform: Platform{OS: "linux", Arch: "arm", ARM: "6"},
+			assets:   []string{"core-linux-armv7"},
+		},
+		{
+			name:       "musl prefers musl build",
+			platform:   Platform{OS: "linux", Arch: "amd64", Libc: "musl"},
+			assets:     []string{"core-linux-amd64", "core-linux-amd64-musl"},
+			wantBinary: "core-linux-amd64-musl",
+		},
+		{
+			name:       "glibc ignores musl build",
+			platform:   linuxAmd64,
+			assets:     []string{"core-linux-amd64-musl", "core-linux-amd64-gnu"},
+			wantBinary: "core-linux-amd64-gnu",
+		},
+		{
+			name:       "darwin universal binary",
+			platform:   Platform{OS: "darwin", Arch: "arm64"},
+			assets:     []string{"core-darwin-amd64", "core-darwin-universal"},
+			wantBinary: "core-darwin-universal",
+		},
+		{
+			name:       "custom template",
+			platform:   linuxAmd64,
+			templates:  []string{"{{.Binary}}_{{.Version}}_{{.OS}}_{{.Arch}}{{.Ext}}"},
+			assets:     []string{"core-linux-amd64", "core_1.2.0_linux_amd64.tar.gz"},
+			wantBinary: "core_1.2.0_linux_amd64.tar.gz",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := NewChecker(CheckerConfig{Platform: tt.platform, AssetTemplates: tt.templates})
+
+			binary, checksum, err := checker.selectAssets(releaseWithAssets(tt.assets...))
+			if tt.wantBinary == "" {
+				if !errors.Is(err, ErrNoMatchingAsset) {
+					t.Fatalf("Expected ErrNoMatchingAsset, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("selectAssets() failed: %v", err)
+			}
+			if binary.Name != tt.wantBinary {
+				t.Errorf("Selected %s, want %s", binary.Name, tt.wantBinary)
+			}
+
+			gotChecksum := ""
+			if checksum != nil {
+				gotChecksum = checksum.Name
+			}
+			if gotChecksum != tt.wantChecksum {
+				t.Errorf("Selected checksum %q, want %q", gotChecksum, tt.wantChecksum)
+			}
+		})
+	}
+}
+
+func TestChecker_SelectAssets_NoMatchListsAvailable(t *testing.T) {
+	checker := NewChecker(CheckerConfig{Platform: Platform{OS: "freebsd", Arch: "amd64"}})
+
+	_, _, err := checker.selectAssets(releaseWithAssets("core-linux-amd64", "core-darwin-arm64", "core-linux-amd64.minisig", "checksums.txt"))
+	if !errors.Is(err, ErrNoMatchingAsset) {
+		t.Fatalf("Expected ErrNoMatchingAsset, got %v", err)
+	}
+
+	msg := err.Error()
+	for _, want := range []string{"freebsd/amd64", "core-freebsd-amd64", "core-linux-amd64, core-darwin-arm64, checksums.txt"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("Expected error to mention %q, got: %s", want, msg)
+		}
+	}
+	if strings.Contains(msg, ".minisig") {
+		t.Errorf("Signatures should not be listed as available binaries: %s", msg)
+	}
+
+	// A release still being uploaded has nothing to install, which is not an error
+	if binary, _, err := checker.selectAssets(releaseWithAssets()); binary != nil || err != nil {
+		t.Errorf("Expected no asset and no error for an empty release, got %v, %v", binary, err)
+	}
+}
+
+func TestChecker_SelectAssets_ChecksumTemplate(t *testing.T) {
+	checker := NewChecker(CheckerConfig{
+		Platform:          Platform{OS: "linux", Arch: "amd64"},
+		ChecksumTemplates: []string{"{{.Binary}}_{{.Version}}_checksums.txt"},
+	})
+
+	_, checksum, err := checker.selectAssets(releaseWithAssets("core-linux-amd64", "checksums.txt", "core_1.2.0_checksums.txt"))
+	if err != nil {
+		t.Fatalf("selectAssets() failed: %v", err)
+	}
+	if checksum == nil || checksum.Name != "core_1.2.0_checksums.txt" {
+		t.Errorf("Expected templated checksum file, got %+v", checksum)
+	}
+}
+
+func TestChecker_SelectAssets_InvalidTemplate(t *testing.T) {
+	checker := NewChecker(CheckerConfig{AssetTemplates: []string{"{{.Binary}-{{.OS}}"}})
+	if _, _, err := checker.selectAssets(releaseWithAssets("core-linux-amd64")); err == nil {
+		t.Error("Expected an error for an unparseable template")
+	}
+
+	checker = NewChecker(CheckerConfig{AssetTemplates: []string{"{{.Binary}}-{{.Platform}}"}})
+	if _, _, err := checker.selectAssets(releaseWithAssets("core-linux-amd64")); err == nil {
+		t.Error("Expected an error for an unknown template field")
+	}
+}
+
+func TestParsePlatform(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Platform
+		wantErr bool
+	}{
+		{"linux/arm64", Platform{OS: "linux", Arch: "arm64"}, false},
+		{"linux/armv7", Platform{OS: "linux", Arch: "arm", ARM: "7"}, false},
+		{"linux/amd64-musl", Platform{OS: "linux", Arch: "amd64", Libc: "musl"}, false},
+		{"linux/amd64-gnu", Platform{OS: "linux", Arch: "amd64"}, false},
+		{"windows/amd64", Platform{OS: "windows", Arch: "amd64"}, false},
+		{"linux", Platform{}, true},
+		{"linux/", Platform{}, true},
+		{"linux/amd64-uclibc", Platform{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParsePlatform(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParsePlatform(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParsePlatform(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+		if !tt.wantErr && tt.input != "linux/amd64-gnu" && got.String() != tt.input {
+			t.Errorf("ParsePlatform(%q).String() = %q", tt.input, got.String())
+		}
+	}
+}
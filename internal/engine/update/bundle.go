@@ -0,0 +1,267 @@
+package update
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// BundleSchemaVersion is the newest bundle.json schema this build reads.
+	BundleSchemaVersion = 1
+	bundleFileName      = "bundle.json"
+)
+
+// ErrInvalidBundle is returned when an offline bundle is incomplete or was
+// written by a newer, incompatible version of CORE CLI.
+var ErrInvalidBundle = errors.New("invalid update bundle")
+
+// Bundle describes an offline update bundle: a directory holding one
+// release asset with its checksum file and signatures, as downloaded by
+// DownloadBundle, and a bundle.json listing them.
+type Bundle struct {
+	SchemaVersion int       `json:"schema_version"`
+	Version       string    `json:"version"`
+	Platform      string    `json:"platform"` // As Platform.String(), e.g. linux/arm64
+	CreatedAt     time.Time `json:"created_at"`
+
+	// File names within the bundle directory. Asset is the release asset as
+	// published, named as in the checksum file.
+	Asset             string `json:"asset"`
+	Checksums         string `json:"checksums,omitempty"`
+	Signature         string `json:"signature,omitempty"`
+	ChecksumSignature string `json:"checksum_signature,omitempty"`
+	// SHA256 is the digest published by the release source, if any.
+	SHA256 string `json:"sha256,omitempty"`
+
+	ReleaseNotes    string   `json:"release_notes,omitempty"`
+	MinUpgradeFrom  string   `json:"min_upgrade_from,omitempty"`
+	BreakingChanges []string `json:"breaking_changes,omitempty"`
+
+	dir string
+}
+
+// Dir returns the directory the bundle was read from or written to.
+func (b *Bundle) Dir() string {
+	return b.dir
+}
+
+// OpenBundle reads the bundle in dir, or described by the bundle.json at
+// path, and checks that the files it lists are present.
+func OpenBundle(path string) (*Bundle, error) {
+	manifestPath := path
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		manifestPath = filepath.Join(path, bundleFileName)
+	}
+
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s not found", ErrInvalidBundle, manifestPath)
+		}
+		return nil, fmt.Errorf("failed to read update bundle: %w", err)
+	}
+
+	var bundle Bundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+	}
+	if bundle.SchemaVersion > BundleSchemaVersion {
+		return nil, fmt.Errorf("%w: schema version %d, this build supports up to %d",
+			ErrInvalidBundle, bundle.SchemaVersion, BundleSchemaVersion)
+	}
+	if bundle.Version == "" || bundle.Asset == "" {
+		return nil, fmt.Errorf("%w: missing version or asset", ErrInvalidBundle)
+	}
+
+	bundle.dir, err = filepath.Abs(filepath.Dir(manifestPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve bundle directory: %w", err)
+	}
+
+	for _, name := range bundle.files() {
+		// Names come from a file that may have been edited; keep them inside the bundle
+		if name != filepath.Base(name) || name == "." || name == ".." {
+			return nil, fmt.Errorf("%w: file name %q is not local to the bundle", ErrInvalidBundle, name)
+		}
+		if _, err := os.Stat(filepath.Join(bundle.dir, name)); err != nil {
+			return nil, fmt.Errorf("%w: %s is missing", ErrInvalidBundle, name)
+		}
+	}
+
+	return &bundle, nil
+}
+
+// UpdateInfo describes installing the bundle over currentVersion. Its URLs
+// point into the bundle directory, so the updater installs from it with the
+// same verification as a download.
+func (b *Bundle) UpdateInfo(currentVersion string) *UpdateInfo {
+	latestVersion := NormalizeVersion(b.Version)
+	meta := ReleaseMetadata{MinUpgradeFrom: b.MinUpgradeFrom, BreakingChanges: b.BreakingChanges}
+	direction := versionDirection(currentVersion, latestVersion)
+
+	return &UpdateInfo{
+		CurrentVersion:       currentVersion,
+		LatestVersion:        latestVersion,
+		Channel:              ChannelStable,
+		UpdateAvailable:      direction == DirectionUpgrade,
+		Direction:            direction,
+		Compatible:           isCompatible(currentVersion, latestVersion, meta),
+		AssetName:            b.Asset,
+		DownloadURL:          b.fileURL(b.Asset),
+		ChecksumURL:          b.fileURL(b.Checksums),
+		SHA256:               b.SHA256,
+		SignatureURL:         b.fileURL(b.Signature),
+		ChecksumSignatureURL: b.fileURL(b.ChecksumSignature),
+		ReleaseNotes:         b.ReleaseNotes,
+		MinUpgradeFrom:       b.MinUpgradeFrom,
+		BreakingChanges:      b.BreakingChanges,
+	}
+}
+
+// files lists the bundle's file names.
+func (b *Bundle) files() []string {
+	var names []string
+	for _, name := range []string{b.Asset, b.Checksums, b.Signature, b.ChecksumSignature} {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// fileURL returns the file:// URL of a bundle file, or "" for none.
+func (b *Bundle) fileURL(name string) string {
+	if name == "" {
+		return ""
+	}
+	p := filepath.ToSlash(filepath.Join(b.dir, name))
+	if !strings.HasPrefix(p, "/") {
+		// Windows drive paths become /C:/...
+		p = "/" + p
+	}
+	return (&url.URL{Scheme: "file", Path: p}).String()
+}
+
+// DownloadBundle downloads the release asset with its checksum file and
+// signatures into dir, for installing on a machine without network access.
+// The asset is verified as ApplyContext would verify it, and raw binaries
+// are validated for platform rather than the running system; archives are
+// validated when the bundle is applied. Nothing is installed.
+func (u *Updater) DownloadBundle(ctx context.Context, dir string, info *UpdateInfo, platform Platform) (*Bundle, error) {
+	if u.config.DownloadURL == "" {
+		return nil, fmt.Errorf("download URL not specified")
+	}
+
+	var bundle *Bundle
+	lock, err := u.lock()
+	if err == nil {
+		defer lock.Release()
+		bundle, err = u.downloadBundle(ctx, dir, info, platform)
+	}
+	if err != nil {
+		u.emit(UpdateProgress{
+			Stage: StageFailed,
+			Error: err,
+		})
+		return nil, err
+	}
+
+	u.emit(UpdateProgress{
+		Stage: StageComplete,
+	})
+	return bundle, nil
+}
+
+func (u *Updater) downloadBundle(ctx context.Context, dir string, info *UpdateInfo, platform Platform) (*Bundle, error) {
+	tmpFile, err := u.download(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("download failed: %w", err)
+	}
+	defer os.Remove(tmpFile)
+
+	if err := u.applyChecksumPolicy(ctx, tmpFile); err != nil {
+		return nil, fmt.Errorf("checksum verification failed: %w", err)
+	}
+	if err := u.verifySignature(ctx, tmpFile); err != nil {
+		return nil, fmt.Errorf("signature verification failed: %w", err)
+	}
+
+	if format, err := detectArchive(u.assetName(), tmpFile); err == nil && format == formatRaw && u.config.ModulePath != "" {
+		if err := validateBinary(tmpFile, platform.OS, platform.Arch, u.config.ModulePath, u.config.ExpectedVersion); err != nil {
+			return nil, fmt.Errorf("downloaded binary rejected: %w", err)
+		}
+	}
+
+	bundle := &Bundle{
+		SchemaVersion:   BundleSchemaVersion,
+		Version:         info.LatestVersion,
+		Platform:        platform.String(),
+		CreatedAt:       time.Now().UTC(),
+		Asset:           u.assetName(),
+		SHA256:          u.config.SHA256,
+		ReleaseNotes:    info.ReleaseNotes,
+		MinUpgradeFrom:  info.MinUpgradeFrom,
+		BreakingChanges: info.BreakingChanges,
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create bundle directory: %w", err)
+	}
+	if bundle.dir, err = filepath.Abs(dir); err != nil {
+		return nil, fmt.Errorf("failed to resolve bundle directory: %w", err)
+	}
+
+	if err := moveFile(tmpFile, filepath.Join(dir, bundle.Asset)); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", bundle.Asset, err)
+	}
+
+	for _, file := range []struct {
+		url  string
+		name *string
+	}{
+		{u.config.ChecksumURL, &bundle.Checksums},
+		{u.config.SignatureURL, &bundle.Signature},
+		{u.config.ChecksumSignatureURL, &bundle.ChecksumSignature},
+	} {
+		if file.url == "" {
+			continue
+		}
+
+		body, err := u.fetch(ctx, file.url)
+		if err != nil {
+			return nil, fmt.Errorf("failed to download %s: %w", file.url, err)
+		}
+
+		name := bundleName(file.url)
+		if err := os.WriteFile(filepath.Join(dir, name), body, 0644); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", name, err)
+		}
+		*file.name = name
+	}
+
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode bundle: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, bundleFileName), append(data, '\n'), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write bundle: %w", err)
+	}
+
+	return bundle, nil
+}
+
+// bundleName returns the file name a release file is stored under.
+func bundleName(rawURL string) string {
+	if parsed, err := url.Parse(rawURL); err == nil && parsed.Path != "" {
+		return path.Base(parsed.Path)
+	}
+	return path.Base(rawURL)
+}
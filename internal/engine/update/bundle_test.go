@@ -0,0 +1,160 @@
+package update
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"aead.dev/minisign"
+)
+
+// newBundle downloads a signed release into a bundle directory.
+func newBundle(t *testing.T, content []byte) (dir, publicKey string) {
+	t.Helper()
+
+	publicKey, privateKey := newTestKey(t)
+	checksums := []byte(fmt.Sprintf("%x  core-linux-amd64\n", sha256.Sum256(content)))
+	files := map[string][]byte{
+		"/v1.2.0/core-linux-amd64":         content,
+		"/v1.2.0/core-linux-amd64.minisig": minisign.Sign(privateKey, content),
+		"/v1.2.0/checksums.txt":            checksums,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	updater := NewUpdater(UpdaterConfig{
+		DownloadURL:  server.URL + "/v1.2.0/core-linux-amd64",
+		ChecksumURL:  server.URL + "/v1.2.0/checksums.txt",
+		SignatureURL: server.URL + "/v1.2.0/core-linux-amd64.minisig",
+		PublicKey:    publicKey,
+		StateDir:     t.TempDir(),
+	})
+
+	info := &UpdateInfo{LatestVersion: "1.2.0", BreakingChanges: []string{"Config moved"}}
+	dir = filepath.Join(t.TempDir(), "bundle")
+	bundle, err := updater.DownloadBundle(context.Background(), dir, info, Platform{OS: "linux", Arch: "amd64"})
+	if err != nil {
+		t.Fatalf("DownloadBundle() failed: %v", err)
+	}
+	if bundle.Checksums != "checksums.txt" || bundle.Signature != "core-linux-amd64.minisig" {
+		t.Errorf("Unexpected bundle files: %+v", bundle)
+	}
+
+	return dir, publicKey
+}
+
+func TestUpdater_DownloadBundle_ApplyOffline(t *testing.T) {
+	content := []byte("bundled binary")
+	dir, publicKey := newBundle(t, content)
+
+	bundle, err := OpenBundle(dir)
+	if err != nil {
+		t.Fatalf("OpenBundle() failed: %v", err)
+	}
+	if bundle.Version != "1.2.0" || bundle.Platform != "linux/amd64" {
+		t.Errorf("Unexpected bundle: %+v", bundle)
+	}
+
+	info := bundle.UpdateInfo("1.0.0")
+	if info.Direction != DirectionUpgrade || len(info.BreakingChanges) != 1 {
+		t.Errorf("Unexpected update info: %+v", info)
+	}
+
+	target := filepath.Join(t.TempDir(), "core")
+	os.WriteFile(target, []byte("old binary"), 0755)
+
+	// The release server is gone; everything comes from the bundle
+	updater := NewUpdater(UpdaterConfig{
+		DownloadURL:  info.DownloadURL,
+		ChecksumURL:  info.ChecksumURL,
+		SignatureURL: info.SignatureURL,
+		AssetName:    info.AssetName,
+		PublicKey:    publicKey,
+		TargetPath:   target,
+	})
+	if err := updater.Apply(); err != nil {
+		t.Fatalf("Apply() from bundle failed: %v", err)
+	}
+
+	installed, _ := os.ReadFile(target)
+	if string(installed) != string(content) {
+		t.Errorf("Installed %q, want %q", installed, content)
+	}
+}
+
+func TestUpdater_DownloadBundle_Tampered(t *testing.T) {
+	dir, publicKey := newBundle(t, []byte("bundled binary"))
+	os.WriteFile(filepath.Join(dir, "core-linux-amd64"), []byte("tampered binary"), 0755)
+
+	bundle, err := OpenBundle(filepath.Join(dir, "bundle.json"))
+	if err != nil {
+		t.Fatalf("OpenBundle() failed: %v", err)
+	}
+	info := bundle.UpdateInfo("1.0.0")
+
+	target := filepath.Join(t.TempDir(), "core")
+	os.WriteFile(target, []byte("old binary"), 0755)
+
+	updater := NewUpdater(UpdaterConfig{
+		DownloadURL:  info.DownloadURL,
+		ChecksumURL:  info.ChecksumURL,
+		SignatureURL: info.SignatureURL,
+		PublicKey:    publicKey,
+		TargetPath:   target,
+	})
+	if err := updater.Apply(); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Expected ErrChecksumMismatch, got %v", err)
+	}
+
+	installed, _ := os.ReadFile(target)
+	if string(installed) != "old binary" {
+		t.Error("A tampered bundle must not be installed")
+	}
+}
+
+func TestOpenBundle_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		bundle Bundle
+		files  []string
+	}{
+		{"newer schema", Bundle{SchemaVersion: BundleSchemaVersion + 1, Version: "1.2.0", Asset: "core"}, []string{"core"}},
+		{"no asset", Bundle{SchemaVersion: 1, Version: "1.2.0"}, nil},
+		{"missing file", Bundle{SchemaVersion: 1, Version: "1.2.0", Asset: "core", Checksums: "checksums.txt"}, []string{"core"}},
+		{"path outside bundle", Bundle{SchemaVersion: 1, Version: "1.2.0", Asset: "../core"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			data, _ := json.Marshal(tt.bundle)
+			os.WriteFile(filepath.Join(dir, "bundle.json"), data, 0644)
+			for _, name := range tt.files {
+				os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644)
+			}
+
+			if _, err := OpenBundle(dir); !errors.Is(err, ErrInvalidBundle) {
+				t.Errorf("Expected ErrInvalidBundle, got %v", err)
+			}
+		})
+	}
+
+	if _, err := OpenBundle(t.TempDir()); !errors.Is(err, ErrInvalidBundle) {
+		t.Errorf("Expected ErrInvalidBundle for a directory without bundle.json, got %v", err)
+	}
+}
@@ -0,0 +1,106 @@
+package update
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const checkCacheFile = "update-check.json"
+
+// cacheEntry is a cached API response together with its validator.
+type cacheEntry struct {
+	ETag      string          `json:"etag,omitempty"`
+	FetchedAt time.Time       `json:"fetched_at"`
+	Body      json.RawMessage `json:"body"`
+}
+
+// fresh reports whether the entry may be served without revalidation.
+func (e cacheEntry) fresh(ttl time.Duration) bool {
+	return ttl > 0 && time.Since(e.FetchedAt) < ttl
+}
+
+// checkCache stores update-check responses keyed by request URL in a single
+// JSON file. A missing or corrupt file behaves like an empty cache.
+type checkCache struct {
+	path string
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+// newCheckCache returns a cache stored in dir, or nil when dir is empty.
+func newCheckCache(dir string) *checkCache {
+	if dir == "" {
+		return nil
+	}
+	return &checkCache{path: filepath.Join(dir, checkCacheFile)}
+}
+
+// load reads the cache file on first use.
+func (c *checkCache) load() {
+	if c.entries != nil {
+		return
+	}
+	c.entries = make(map[string]cacheEntry)
+
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, &c.entries); err != nil {
+		c.entries = make(map[string]cacheEntry)
+	}
+}
+
+// get returns the cached response for key.
+func (c *checkCache) get(key string) (cacheEntry, bool) {
+	if c == nil {
+		return cacheEntry{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.load()
+	entry, ok := c.entries[key]
+	return entry, ok
+}
+
+// put stores a response for key and persists the cache.
+func (c *checkCache) put(key string, entry cacheEntry) error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.load()
+	c.entries[key] = entry
+	return c.save()
+}
+
+// save writes the cache file atomically.
+func (c *checkCache) save() error {
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	data, err := json.Marshal(c.entries)
+	if err != nil {
+		return fmt.Errorf("failed to encode update cache: %w", err)
+	}
+
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write update cache: %w", err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write update cache: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package update
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newCachingReleaseServer serves a single release with an ETag and counts
+// full responses and 304s separately.
+func newCachingReleaseServer(t *testing.T, full, notModified *int32) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"r1"` {
+			atomic.AddInt32(notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		atomic.AddInt32(full, 1)
+		w.Header().Set("ETag", `"r1"`)
+		json.NewEncoder(w).Encode(GitHubRelease{TagName: "v1.2.0"})
+	}))
+}
+
+func TestChecker_Cache_FreshHitSkipsNetwork(t *testing.T) {
+	var full, notModified int32
+	server := newCachingReleaseServer(t, &full, &notModified)
+	defer server.Close()
+
+	config := CheckerConfig{
+		APIBaseURL:       server.URL,
+		GitHubAPIBaseURL: server.URL,
+		GitHubOwner:      "Tfc538",
+		GitHubRepo:       "core-cli",
+		CurrentVersion:   "1.0.0",
+		CacheDir:         t.TempDir(),
+		CacheTTL:         time.Hour,
+	}
+
+	for i := 0; i < 3; i++ {
+		info, err := NewChecker(config).Check()
+		if err != nil {
+			t.Fatalf("Check() failed: %v", err)
+		}
+		if info.LatestVersion != "1.2.0" {
+			t.Errorf("Expected 1.2.0, got %s", info.LatestVersion)
+		}
+	}
+
+	if full != 1 || notModified != 0 {
+		t.Errorf("Expected a single request, got %d full and %d conditional", full, notModified)
+	}
+}
+
+func TestChecker_Cache_RevalidatesWithETag(t *testing.T) {
+	var full, notModified int32
+	server := newCachingReleaseServer(t, &full, &notModified)
+	defer server.Close()
+
+	config := CheckerConfig{
+		APIBaseURL:       server.URL,
+		GitHubAPIBaseURL: server.URL,
+		GitHubOwner:      "Tfc538",
+		GitHubRepo:       "core-cli",
+		CurrentVersion:   "1.0.0",
+		CacheDir:         t.TempDir(),
+	}
+
+	for i := 0; i < 2; i++ {
+		info, err := NewChecker(config).Check()
+		if err != nil {
+			t.Fatalf("Check() failed: %v", err)
+		}
+		if info.LatestVersion != "1.2.0" {
+			t.Errorf("Expected 1.2.0 from cache, got %s", info.LatestVersion)
+		}
+	}
+
+	if full != 1 || notModified != 1 {
+		t.Errorf("Expected 1 full and 1 conditional request, got %d and %d", full, notModified)
+	}
+}
+
+func TestChecker_Cache_Refresh(t *testing.T) {
+	var full, notModified int32
+	server := newCachingReleaseServer(t, &full, &notModified)
+	defer server.Close()
+
+	config := CheckerConfig{
+		APIBaseURL:       server.URL,
+		GitHubAPIBaseURL: server.URL,
+		GitHubOwner:      "Tfc538",
+		GitHubRepo:       "core-cli",
+		CurrentVersion:   "1.0.0",
+		CacheDir:         t.TempDir(),
+		CacheTTL:         time.Hour,
+	}
+
+	if _, err := NewChecker(config).Check(); err != nil {
+		t.Fatalf("Check() failed: %v", err)
+	}
+
+	config.Refresh = true
+	if _, err := NewChecker(config).Check(); err != nil {
+		t.Fatalf("Check() with refresh failed: %v", err)
+	}
+
+	if full != 2 || notModified != 0 {
+		t.Errorf("Expected refresh to bypass the cache, got %d full and %d conditional", full, notModified)
+	}
+}
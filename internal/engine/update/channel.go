@@ -0,0 +1,120 @@
+package update
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// Channel selects which kind of releases an update check considers.
+type Channel string
+
+const (
+	// ChannelStable only considers full releases.
+	ChannelStable Channel = "stable"
+	// ChannelBeta considers full releases and -beta.N prereleases.
+	ChannelBeta Channel = "beta"
+	// ChannelNightly considers full releases, betas and -nightly.YYYYMMDD builds.
+	ChannelNightly Channel = "nightly"
+)
+
+// Channels lists the supported release channels, most stable first.
+var Channels = []Channel{ChannelStable, ChannelBeta, ChannelNightly}
+
+// ParseChannel validates a channel name. An empty name selects ChannelStable.
+func ParseChannel(name string) (Channel, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return ChannelStable, nil
+	}
+	for _, ch := range Channels {
+		if string(ch) == name {
+			return ch, nil
+		}
+	}
+	return "", fmt.Errorf("unknown release channel %q (expected stable, beta or nightly)", name)
+}
+
+// rank orders channels by how unstable the releases they admit may be.
+func (ch Channel) rank() int {
+	switch ch {
+	case ChannelBeta:
+		return 1
+	case ChannelNightly:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// channelOf classifies a version by its prerelease identifier. Versions with
+// unrecognised prerelease tags (e.g. -rc.1) belong to no channel.
+func channelOf(v *semver.Version) (Channel, bool) {
+	pre := v.Prerelease()
+	switch {
+	case pre == "":
+		return ChannelStable, true
+	case pre == "beta" || strings.HasPrefix(pre, "beta."):
+		return ChannelBeta, true
+	case pre == "nightly" || strings.HasPrefix(pre, "nightly."):
+		return ChannelNightly, true
+	}
+	return "", false
+}
+
+// includes reports whether a release of the given version is offered on ch.
+// Each channel also offers the releases of the more stable channels.
+func (ch Channel) includes(v *semver.Version) bool {
+	releaseChannel, ok := channelOf(v)
+	if !ok {
+		return false
+	}
+	return releaseChannel.rank() <= ch.rank()
+}
+
+// offers reports whether a release of version v is offered on ch. A channel
+// declared by the release source takes precedence over the version.
+func (ch Channel) offers(release *Release, v *semver.Version) bool {
+	if release.Channel != "" {
+		return release.Channel.rank() <= ch.rank()
+	}
+	return ch.includes(v)
+}
+
+// selectRelease picks the highest semver release offered on the channel,
+// ignoring drafts and tags that are not valid semantic versions.
+func (c *Checker) selectRelease(releases []Release, ch Channel) (*Release, error) {
+	return highestRelease(releases, ch)
+}
+
+// highestRelease implements selectRelease for release sources that have to
+// pick the latest release themselves.
+func highestRelease(releases []Release, ch Channel) (*Release, error) {
+	var (
+		best        *Release
+		bestVersion *semver.Version
+	)
+
+	for i := range releases {
+		release := &releases[i]
+		if release.Draft {
+			continue
+		}
+
+		v, err := semver.NewVersion(NormalizeVersion(release.TagName))
+		if err != nil || !ch.offers(release, v) {
+			continue
+		}
+
+		if bestVersion == nil || v.GreaterThan(bestVersion) {
+			best, bestVersion = release, v
+		}
+	}
+
+	if best == nil {
+		return nil, fmt.Errorf("no releases found on the %s channel", ch)
+	}
+
+	return best, nil
+}
@@ -0,0 +1,117 @@
+package update
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseChannel(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Channel
+		wantErr bool
+	}{
+		{"", ChannelStable, false},
+		{"stable", ChannelStable, false},
+		{"Beta", ChannelBeta, false},
+		{" nightly ", ChannelNightly, false},
+		{"canary", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseChannel(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseChannel(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("ParseChannel(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestChecker_SelectRelease(t *testing.T) {
+	checker := NewChecker(CheckerConfig{})
+
+	releases := []Release{
+		{TagName: "v1.1.0"},
+		{TagName: "v1.2.0-beta.1", Prerelease: true},
+		{TagName: "v1.2.0-beta.2", Prerelease: true},
+		{TagName: "v1.2.0-rc.1", Prerelease: true},
+		{TagName: "v1.3.0-nightly.20260101", Prerelease: true},
+		{TagName: "v1.4.0", Draft: true},
+		{TagName: "not-a-version"},
+	}
+
+	tests := []struct {
+		channel Channel
+		want    string
+	}{
+		{ChannelStable, "v1.1.0"},
+		{ChannelBeta, "v1.2.0-beta.2"},
+		{ChannelNightly, "v1.3.0-nightly.20260101"},
+	}
+
+	for _, tt := range tests {
+		release, err := checker.selectRelease(releases, tt.channel)
+		if err != nil {
+			t.Fatalf("selectRelease(%s) failed: %v", tt.channel, err)
+		}
+		if release.TagName != tt.want {
+			t.Errorf("selectRelease(%s) = %s, want %s", tt.channel, release.TagName, tt.want)
+		}
+	}
+
+	// Stable releases supersede older betas
+	releases = append(releases, Release{TagName: "v1.2.0"})
+	release, err := checker.selectRelease(releases, ChannelBeta)
+	if err != nil {
+		t.Fatalf("selectRelease(beta) failed: %v", err)
+	}
+	if release.TagName != "v1.2.0" {
+		t.Errorf("selectRelease(beta) = %s, want v1.2.0", release.TagName)
+	}
+
+	if _, err := checker.selectRelease(nil, ChannelStable); err == nil {
+		t.Error("selectRelease() should fail without releases")
+	}
+}
+
+func TestChecker_Check_BetaChannel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/test/test/releases" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]GitHubRelease{
+			{TagName: "v1.1.0"},
+			{TagName: "v1.2.0-beta.1", Prerelease: true},
+		})
+	}))
+	defer server.Close()
+
+	checker := NewChecker(CheckerConfig{
+		APIBaseURL:       server.URL,
+		GitHubAPIBaseURL: server.URL,
+		GitHubOwner:      "test",
+		GitHubRepo:       "test",
+		CurrentVersion:   "1.1.0",
+		Channel:          ChannelBeta,
+	})
+
+	info, err := checker.Check()
+	if err != nil {
+		t.Fatalf("Check() failed: %v", err)
+	}
+	if info.LatestVersion != "1.2.0-beta.1" {
+		t.Errorf("Expected 1.2.0-beta.1, got %s", info.LatestVersion)
+	}
+	if !info.UpdateAvailable {
+		t.Error("Expected beta update to be available")
+	}
+	if info.Channel != ChannelBeta {
+		t.Errorf("Expected beta channel, got %s", info.Channel)
+	}
+}
@@ -1,13 +1,12 @@
 package update
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
-	"runtime"
 	"strings"
-	"time"
 
 	"github.com/Masterminds/semver/v3"
 )
@@ -17,10 +16,12 @@ const (
 	defaultGitHubAPIBaseURL = "https://api.github.com"
 )
 
-// Checker is responsible for checking GitHub Releases for updates.
+// Checker is responsible for checking a release source for updates.
 type Checker struct {
-	config CheckerConfig
-	client *http.Client
+	config    CheckerConfig
+	fetch     *fetcher
+	source    ReleaseSource
+	sourceErr error
 }
 
 // NewChecker creates a new update checker.
@@ -31,70 +32,232 @@ func NewChecker(config CheckerConfig) *Checker {
 	if strings.TrimSpace(config.GitHubAPIBaseURL) == "" {
 		config.GitHubAPIBaseURL = defaultGitHubAPIBaseURL
 	}
+	if config.Channel == "" {
+		config.Channel = ChannelStable
+	}
+
+	f := newFetcher(config)
+	// An invalid source type is reported by the first check
+	source, err := newReleaseSource(config, f)
 
 	return &Checker{
-		config: config,
-		client: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		config:    config,
+		fetch:     f,
+		source:    source,
+		sourceErr: err,
 	}
 }
 
-// Check performs the update check against GitHub Releases.
+// Source returns the release source the checker reads from.
+func (c *Checker) Source() ReleaseSource {
+	return c.source
+}
+
+// Endpoints returns the URLs an update check contacts: the core backend's
+// health endpoint when it is consulted, the release source, and the
+// configured mirrors. They are meant for connectivity diagnostics.
+func (c *Checker) Endpoints() []string {
+	var endpoints []string
+	if c.sourceErr == nil && c.useCoreAPI() {
+		endpoints = append(endpoints, strings.TrimRight(c.config.APIBaseURL, "/")+"/healthz")
+	}
+	if source, ok := c.source.(endpointSource); ok {
+		endpoints = append(endpoints, source.endpoint())
+	}
+	return append(endpoints, c.config.Mirrors...)
+}
+
+// Check performs the update check against the configured release source.
 func (c *Checker) Check() (*UpdateInfo, error) {
+	return c.CheckContext(context.Background())
+}
+
+// CheckContext is like Check but aborts the HTTP requests when ctx is done.
+func (c *Checker) CheckContext(ctx context.Context) (*UpdateInfo, error) {
+	if c.sourceErr != nil {
+		return nil, c.sourceErr
+	}
+
 	var (
 		latestVersion string
-		release       *GitHubRelease
+		release       *Release
+		core          coreVersionData
 		err           error
 	)
 
-	if c.useCoreAPI() {
-		latestVersion, err = c.getLatestVersionFromCore()
+	if c.config.Channel != ChannelStable {
+		// Prereleases are never returned by /releases/latest
+		release, err = c.getLatestReleaseForChannel(ctx)
 		if err != nil {
 			return nil, fmt.Errorf("failed to check for updates: %w", err)
 		}
-		release, err = c.getLatestReleaseFromGitHub()
+		latestVersion = c.parseVersion(release.TagName)
+	} else if c.useCoreAPI() {
+		latest, err := c.getLatestVersionFromCore(ctx)
 		if err != nil {
 			return nil, fmt.Errorf("failed to check for updates: %w", err)
 		}
+		// Install exactly the release the backend reports, so the binary
+		// matches the version the updater validates it against
+		release, err = c.source.ReleaseByTag(ctx, "v"+c.parseVersion(latest.Version))
+		switch {
+		case err == nil:
+			core = latest
+		case errors.Is(err, ErrReleaseNotFound):
+			// The tag is not published yet; the source's latest release is
+			// installed instead, and backend metadata only describes the
+			// version it reports
+			release, err = c.source.LatestRelease(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to check for updates: %w", err)
+			}
+			if c.parseVersion(release.TagName) == c.parseVersion(latest.Version) {
+				core = latest
+			}
+		default:
+			return nil, fmt.Errorf("failed to check for updates: %w", err)
+		}
+		latestVersion = c.parseVersion(release.TagName)
 	} else {
-		release, err = c.getLatestReleaseFromGitHub()
+		release, err = c.source.LatestRelease(ctx)
 		if err != nil {
 			return nil, fmt.Errorf("failed to check for updates: %w", err)
 		}
 		latestVersion = c.parseVersion(release.TagName)
 	}
 
-	currentVersion := c.config.CurrentVersion
+	return c.resolveUpdateInfo(ctx, release, latestVersion, core)
+}
 
-	// Check if update is available
-	updateAvailable, compatible := c.compareVersions(currentVersion, latestVersion)
+// CheckVersion resolves a specific release by version, e.g. to reinstall or
+// downgrade. The returned UpdateInfo reports the direction of the change;
+// UpdateAvailable is only set for upgrades.
+func (c *Checker) CheckVersion(targetVersion string) (*UpdateInfo, error) {
+	return c.CheckVersionContext(context.Background(), targetVersion)
+}
 
-	// Find download URL for current platform
-	downloadURL, checksumURL := c.findAssetURLs(release)
+// CheckVersionContext is like CheckVersion but aborts the HTTP requests when
+// ctx is done.
+func (c *Checker) CheckVersionContext(ctx context.Context, targetVersion string) (*UpdateInfo, error) {
+	if c.sourceErr != nil {
+		return nil, c.sourceErr
+	}
 
-	return &UpdateInfo{
-		CurrentVersion:  currentVersion,
-		LatestVersion:   latestVersion,
-		UpdateAvailable: updateAvailable,
-		Compatible:      compatible,
-		DownloadURL:     downloadURL,
-		ChecksumURL:     checksumURL,
-		ReleaseNotes:    release.Body,
-	}, nil
+	targetVersion = c.parseVersion(strings.TrimSpace(targetVersion))
+	if targetVersion == "" {
+		return nil, fmt.Errorf("no version specified")
+	}
+
+	release, err := c.source.ReleaseByTag(ctx, "v"+targetVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find release %s: %w", targetVersion, err)
+	}
+
+	return c.resolveUpdateInfo(ctx, release, c.parseVersion(release.TagName), coreVersionData{})
 }
 
-// GitHubRelease represents a GitHub release response.
-type GitHubRelease struct {
-	TagName string        `json:"tag_name"`
-	Body    string        `json:"body"`
-	Assets  []GitHubAsset `json:"assets"`
+// resolveUpdateInfo builds the UpdateInfo for a release, applying its
+// compatibility metadata and mirrors, and suggesting a stepping-stone
+// release when the upgrade cannot be made directly. core holds what the
+// core backend reported about this release, if anything.
+func (c *Checker) resolveUpdateInfo(ctx context.Context, release *Release, latestVersion string, core coreVersionData) (*UpdateInfo, error) {
+	meta, err := c.getReleaseMetadata(ctx, release)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for updates: %w", err)
+	}
+	meta = meta.merge(ReleaseMetadata{
+		MinUpgradeFrom:  core.MinUpgradeFrom,
+		BreakingChanges: core.BreakingChanges,
+	})
+
+	info := c.buildUpdateInfo(release, latestVersion, meta)
+	if info.DownloadURL == "" && info.Direction != DirectionNone {
+		if _, _, err := c.selectAssets(release); err != nil {
+			return nil, fmt.Errorf("failed to check for updates: %w", err)
+		}
+	}
+	info.DownloadURLs = c.downloadURLs(info, c.parseVersion(release.TagName), core.MirrorURL)
+
+	if info.Direction == DirectionUpgrade && !info.Compatible {
+		info.SteppingStone, err = c.findSteppingStone(ctx, info.CurrentVersion, info.LatestVersion)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find an intermediate release: %w", err)
+		}
+	}
+
+	return info, nil
 }
 
-// GitHubAsset represents a release asset.
-type GitHubAsset struct {
-	Name        string `json:"name"`
-	DownloadURL string `json:"browser_download_url"`
+// downloadURLs lists where the selected asset can be downloaded, in
+// preference order: the core backend mirror, configured mirrors, then the
+// release source itself.
+func (c *Checker) downloadURLs(info *UpdateInfo, releaseVersion, backendMirror string) []string {
+	if info.DownloadURL == "" {
+		return nil
+	}
+
+	name := info.AssetName
+	if name == "" {
+		_, name = splitURL(info.DownloadURL)
+	}
+
+	var urls []string
+	for _, base := range append([]string{backendMirror}, c.config.Mirrors...) {
+		if strings.TrimSpace(base) != "" {
+			urls = append(urls, mirrorURL(strings.TrimSpace(base), releaseVersion, name))
+		}
+	}
+	return append(urls, info.DownloadURL)
+}
+
+// buildUpdateInfo compares the release against the running version and
+// resolves the platform assets.
+func (c *Checker) buildUpdateInfo(release *Release, latestVersion string, meta ReleaseMetadata) *UpdateInfo {
+	currentVersion := c.config.CurrentVersion
+
+	// Check which way the version would move
+	direction, _ := c.compareVersions(currentVersion, latestVersion)
+	compatible := isCompatible(currentVersion, latestVersion, meta)
+
+	// Find download URL for current platform
+	downloadURL, checksumURL := c.findAssetURLs(release)
+	signatureURL, checksumSignatureURL := c.findSignatureURLs(release, downloadURL, checksumURL)
+
+	// Sources such as manifests publish digests and signatures per asset
+	var asset Asset
+	for _, a := range release.Assets {
+		if downloadURL != "" && a.DownloadURL == downloadURL {
+			asset = a
+			break
+		}
+	}
+	if signatureURL == "" {
+		signatureURL = asset.SignatureURL
+	}
+
+	return &UpdateInfo{
+		CurrentVersion:          currentVersion,
+		LatestVersion:           latestVersion,
+		Channel:                 c.config.Channel,
+		UpdateAvailable:         direction == DirectionUpgrade,
+		Direction:               direction,
+		Compatible:              compatible,
+		AssetName:               assetNameForURL(release, downloadURL),
+		DownloadURL:             downloadURL,
+		ChecksumURL:             checksumURL,
+		SHA256:                  asset.SHA256,
+		Size:                    asset.Size,
+		SignatureURL:            signatureURL,
+		ChecksumSignatureURL:    checksumSignatureURL,
+		AssetID:                 asset.ID,
+		AssetAPIURL:             asset.APIURL,
+		ChecksumAPIURL:          assetAPIURL(release, checksumURL),
+		SignatureAPIURL:         assetAPIURL(release, signatureURL),
+		ChecksumSignatureAPIURL: assetAPIURL(release, checksumSignatureURL),
+		ReleaseNotes:            release.Body,
+		MinUpgradeFrom:          meta.MinUpgradeFrom,
+		BreakingChanges:         meta.BreakingChanges,
+	}
 }
 
 type coreVersionResponse struct {
@@ -104,12 +267,21 @@ type coreVersionResponse struct {
 }
 
 type coreVersionData struct {
-	Version   string `json:"version"`
-	Commit    string `json:"commit"`
-	BuildDate string `json:"build_date"`
+	Version         string   `json:"version"`
+	Commit          string   `json:"commit"`
+	BuildDate       string   `json:"build_date"`
+	MinUpgradeFrom  string   `json:"min_upgrade_from,omitempty"`
+	BreakingChanges []string `json:"breaking_changes,omitempty"`
+	MirrorURL       string   `json:"mirror_url,omitempty"`
 }
 
+// useCoreAPI reports whether the core backend decides the latest version.
+// It only describes the official GitHub releases.
 func (c *Checker) useCoreAPI() bool {
+	if _, ok := c.source.(*GitHubSource); !ok {
+		return false
+	}
+
 	apiBase := strings.TrimSpace(c.config.APIBaseURL)
 	if apiBase == "" {
 		return false
@@ -124,139 +296,158 @@ func (c *Checker) useCoreAPI() bool {
 	return true
 }
 
-func (c *Checker) getLatestVersionFromCore() (string, error) {
+func (c *Checker) getLatestVersionFromCore(ctx context.Context) (coreVersionData, error) {
 	baseURL := strings.TrimRight(c.config.APIBaseURL, "/")
 	url := fmt.Sprintf("%s/api/v1/version/latest", baseURL)
 
-	req, err := http.NewRequest(http.MethodGet, url, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return "", fmt.Errorf("failed to create core API request: %w", err)
+		return coreVersionData{}, fmt.Errorf("failed to create core API request: %w", err)
 	}
 
-	resp, err := c.client.Do(req)
+	status, header, body, err := c.fetch.get(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to fetch core API version: %w", err)
+		if ctx.Err() != nil {
+			return coreVersionData{}, fmt.Errorf("failed to fetch core API version: %w", err)
+		}
+		return coreVersionData{}, &APIError{Host: "core", Err: ErrNetwork, cause: err}
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("core API returned %d: %s", resp.StatusCode, string(body))
+	if status != http.StatusOK {
+		return coreVersionData{}, newAPIError("core", status, header, body)
 	}
 
 	var payload coreVersionResponse
-	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
-		return "", fmt.Errorf("failed to parse core API response: %w", err)
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return coreVersionData{}, fmt.Errorf("failed to parse core API response: %w", err)
 	}
 	if payload.Status != "ok" || payload.Data.Version == "" {
-		return "", fmt.Errorf("core API returned invalid response")
+		return coreVersionData{}, fmt.Errorf("core API returned invalid response")
 	}
 
-	return payload.Data.Version, nil
+	return payload.Data, nil
 }
 
-// getLatestReleaseFromGitHub fetches the latest release from GitHub.
-func (c *Checker) getLatestReleaseFromGitHub() (*GitHubRelease, error) {
-	baseURL := strings.TrimRight(c.config.GitHubAPIBaseURL, "/")
-	url := fmt.Sprintf("%s/repos/%s/%s/releases/latest",
-		baseURL, c.config.GitHubOwner, c.config.GitHubRepo)
-
-	req, err := http.NewRequest(http.MethodGet, url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create GitHub request: %w", err)
-	}
-
-	token := strings.TrimSpace(c.config.GitHubToken)
-	if token != "" {
-		req.Header.Set("Authorization", "Bearer "+token)
-		req.Header.Set("Accept", "application/vnd.github+json")
-	}
-
-	resp, err := c.client.Do(req)
+// getLatestReleaseForChannel lists recent releases and picks the highest
+// version offered on the configured channel.
+func (c *Checker) getLatestReleaseForChannel(ctx context.Context) (*Release, error) {
+	releases, err := c.source.ListReleases(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch GitHub release: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("GitHub API returned %d: %s", resp.StatusCode, string(body))
-	}
-
-	var release GitHubRelease
-	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
-		return nil, fmt.Errorf("failed to parse GitHub response: %w", err)
-	}
-
-	return &release, nil
+	return c.selectRelease(releases, c.config.Channel)
 }
 
 // parseVersion extracts a semantic version from a git tag.
 func (c *Checker) parseVersion(tag string) string {
-	// Remove leading 'v' if present
-	if strings.HasPrefix(tag, "v") {
-		tag = tag[1:]
-	}
-	return tag
+	return NormalizeVersion(tag)
+}
+
+// NormalizeVersion strips a leading 'v' from a tag or user-supplied version.
+func NormalizeVersion(tag string) string {
+	return strings.TrimPrefix(strings.TrimSpace(tag), "v")
 }
 
 // compareVersions compares two semantic versions.
-// Returns (updateAvailable, compatible).
-func (c *Checker) compareVersions(currentStr, latestStr string) (bool, bool) {
+// Returns the direction of moving from current to latest, and whether the
+// move is compatible.
+func (c *Checker) compareVersions(currentStr, latestStr string) (Direction, bool) {
+	// Without release metadata, only the default major-version rule applies
+	return versionDirection(currentStr, latestStr), isCompatible(currentStr, latestStr, ReleaseMetadata{})
+}
+
+// versionDirection returns the direction of moving from current to latest.
+// Unparseable current versions (e.g. "dev") always upgrade; unparseable
+// latest versions never move.
+func versionDirection(currentStr, latestStr string) Direction {
 	currentVersion, err := semver.NewVersion(currentStr)
 	if err != nil {
-		// If current version is unparseable (e.g., "dev"), always consider update compatible
-		return true, true
+		return DirectionUpgrade
 	}
 
 	latestVersion, err := semver.NewVersion(latestStr)
 	if err != nil {
-		// If latest version is unparseable, no update available
-		return false, false
+		return DirectionNone
 	}
 
-	// Update is available if latest > current
-	updateAvailable := latestVersion.GreaterThan(currentVersion)
-
-	// Compatible if major version matches (basic compatibility check)
-	// For now, always consider compatible if versions are parseable
-	compatible := true
+	switch latestVersion.Compare(currentVersion) {
+	case 1:
+		return DirectionUpgrade
+	case -1:
+		return DirectionDowngrade
+	}
+	return DirectionNone
+}
 
-	return updateAvailable, compatible
+// findAssetURLs locates the binary for the target platform and the
+// release's checksum file by exact name. Either is empty when missing.
+func (c *Checker) findAssetURLs(release *Release) (downloadURL, checksumURL string) {
+	// A missing binary is reported by resolveUpdateInfo
+	binary, checksum, _ := c.selectAssets(release)
+	if binary != nil {
+		downloadURL = binary.DownloadURL
+	}
+	if checksum != nil {
+		checksumURL = checksum.DownloadURL
+	}
+	return downloadURL, checksumURL
 }
 
-// findAssetURLs locates the correct binary for the current platform.
-func (c *Checker) findAssetURLs(release *GitHubRelease) (downloadURL, checksumURL string) {
-	goos := runtime.GOOS
-	goarch := runtime.GOARCH
+// assetNameForURL returns the name of the release asset served at url.
+func assetNameForURL(release *Release, url string) string {
+	if url == "" {
+		return ""
+	}
+	for _, asset := range release.Assets {
+		if asset.DownloadURL == url {
+			return asset.Name
+		}
+	}
+	return ""
+}
 
-	// Normalize architecture names if needed
-	if goarch == "amd64" {
-		goarch = "amd64"
+// assetAPIURL returns the API URL of the release asset served at url.
+func assetAPIURL(release *Release, url string) string {
+	if url == "" {
+		return ""
+	}
+	for _, asset := range release.Assets {
+		if asset.DownloadURL == url {
+			return asset.APIURL
+		}
 	}
+	return ""
+}
 
-	// Build expected binary name patterns
-	patterns := []string{
-		fmt.Sprintf("core-%s-%s", goos, goarch),
-		fmt.Sprintf("core-%s-%s.exe", goos, goarch),
-		fmt.Sprintf("core-%s-%s.tar.gz", goos, goarch),
-		fmt.Sprintf("core-%s-%s.zip", goos, goarch),
+// signatureExt is the file extension of detached minisign signatures.
+const signatureExt = ".minisig"
+
+// findSignatureURLs locates the detached minisign signatures published next
+// to the selected binary and checksum file.
+func (c *Checker) findSignatureURLs(release *Release, downloadURL, checksumURL string) (signatureURL, checksumSignatureURL string) {
+	urls := make(map[string]string, len(release.Assets))
+	for _, asset := range release.Assets {
+		urls[asset.Name] = asset.DownloadURL
 	}
 
 	for _, asset := range release.Assets {
-		// Look for binary matching current platform
-		for _, pattern := range patterns {
-			if strings.Contains(asset.Name, pattern) {
-				downloadURL = asset.DownloadURL
-				break
-			}
+		if !strings.HasSuffix(asset.Name, signatureExt) {
+			continue
 		}
 
-		// Look for checksums file
-		if strings.Contains(asset.Name, "checksums") {
-			checksumURL = asset.DownloadURL
+		signed, ok := urls[strings.TrimSuffix(asset.Name, signatureExt)]
+		if !ok || signed == "" {
+			continue
+		}
+
+		switch signed {
+		case downloadURL:
+			signatureURL = asset.DownloadURL
+		case checksumURL:
+			checksumSignatureURL = asset.DownloadURL
 		}
 	}
 
-	return downloadURL, checksumURL
+	return signatureURL, checksumSignatureURL
 }
@@ -2,10 +2,14 @@ package update
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestChecker_Check(t *testing.T) {
@@ -86,11 +90,91 @@ func TestChecker_Check(t *testing.T) {
 	}
 }
 
+func TestChecker_Check_BackendVersionDiffers(t *testing.T) {
+	binary := "core-" + runtime.GOOS + "-" + runtime.GOARCH
+	release := func(version string) GitHubRelease {
+		return GitHubRelease{
+			TagName: "v" + version,
+			Assets: []GitHubAsset{{
+				Name:        binary,
+				DownloadURL: "https://github.com/o/r/releases/download/v" + version + "/" + binary,
+			}},
+		}
+	}
+
+	tests := []struct {
+		name           string
+		backendVersion string
+		repoReadable   bool
+		wantVersion    string
+		wantErr        error
+	}{
+		{"backend behind the source", "1.2.0", true, "1.2.0", nil},
+		{"backend release not published", "1.4.0", true, "1.3.0", nil},
+		// A missing or private repository is not a missing tag
+		{"repository not readable", "1.4.0", false, "", ErrRepoNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/api/v1/version/latest":
+					json.NewEncoder(w).Encode(coreVersionResponse{
+						Status: "ok",
+						Data:   coreVersionData{Version: tt.backendVersion},
+					})
+				case "/gh/repos/o/r/releases/latest", "/gh/repos/o/r/releases/tags/v1.3.0":
+					json.NewEncoder(w).Encode(release("1.3.0"))
+				case "/gh/repos/o/r/releases/tags/v1.2.0":
+					json.NewEncoder(w).Encode(release("1.2.0"))
+				case "/gh/repos/o/r":
+					if !tt.repoReadable {
+						http.NotFound(w, r)
+						return
+					}
+					w.Write([]byte(`{"full_name": "o/r"}`))
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer server.Close()
+
+			checker := NewChecker(CheckerConfig{
+				APIBaseURL:       server.URL,
+				GitHubAPIBaseURL: server.URL + "/gh",
+				GitHubOwner:      "o",
+				GitHubRepo:       "r",
+				CurrentVersion:   "1.0.0",
+			})
+
+			info, err := checker.Check()
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) || errors.Is(err, ErrReleaseNotFound) {
+					t.Errorf("Check() = %+v, %v; want %v without falling back", info, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Check() failed: %v", err)
+			}
+
+			// The version validated after download must be the one downloaded
+			if info.LatestVersion != tt.wantVersion {
+				t.Errorf("LatestVersion = %s, want %s", info.LatestVersion, tt.wantVersion)
+			}
+			if want := "/v" + tt.wantVersion + "/" + binary; !strings.HasSuffix(info.DownloadURL, want) {
+				t.Errorf("DownloadURL = %s, want the asset of v%s", info.DownloadURL, tt.wantVersion)
+			}
+		})
+	}
+}
+
 func TestChecker_FindAssetURLs(t *testing.T) {
 	checker := NewChecker(CheckerConfig{})
 
-	release := &GitHubRelease{
-		Assets: []GitHubAsset{
+	release := &Release{
+		Assets: []Asset{
 			{
 				Name:        "core-linux-amd64",
 				DownloadURL: "https://example.com/core-linux-amd64",
@@ -137,7 +221,8 @@ func TestChecker_CompareVersions(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		available, compatible := checker.compareVersions(tt.current, tt.latest)
+		direction, compatible := checker.compareVersions(tt.current, tt.latest)
+		available := direction == DirectionUpgrade
 		if available != tt.wantAvailable {
 			t.Errorf("compareVersions(%s, %s): available=%v, want %v",
 				tt.current, tt.latest, available, tt.wantAvailable)
@@ -174,8 +259,8 @@ func TestChecker_ParseVersion(t *testing.T) {
 func TestChecker_MultiPlatformAssets(t *testing.T) {
 	checker := NewChecker(CheckerConfig{})
 
-	release := &GitHubRelease{
-		Assets: []GitHubAsset{
+	release := &Release{
+		Assets: []Asset{
 			{Name: "core-linux-amd64", DownloadURL: "https://example.com/linux-amd64"},
 			{Name: "core-linux-arm64", DownloadURL: "https://example.com/linux-arm64"},
 			{Name: "core-darwin-amd64", DownloadURL: "https://example.com/darwin-amd64"},
@@ -305,7 +390,197 @@ func TestChecker_Timeout(t *testing.T) {
 		CurrentVersion: "1.0.0",
 	})
 
-	if checker.client.Timeout.Seconds() != 10 {
-		t.Errorf("Expected 10 second timeout, got %v", checker.client.Timeout)
+	if checker.fetch.client.Timeout.Seconds() != 10 {
+		t.Errorf("Expected 10 second timeout, got %v", checker.fetch.client.Timeout)
+	}
+}
+
+func TestChecker_CompareVersions_Direction(t *testing.T) {
+	checker := NewChecker(CheckerConfig{})
+
+	tests := []struct {
+		current string
+		target  string
+		want    Direction
+	}{
+		{"1.0.0", "1.1.0", DirectionUpgrade},
+		{"1.1.0", "1.0.0", DirectionDowngrade},
+		{"1.1.0", "1.1.0", DirectionNone},
+		{"1.1.0", "1.1.0-beta.1", DirectionDowngrade},
+		{"dev", "0.1.0", DirectionUpgrade},
+		{"1.0.0", "garbage", DirectionNone},
+	}
+
+	for _, tt := range tests {
+		direction, _ := checker.compareVersions(tt.current, tt.target)
+		if direction != tt.want {
+			t.Errorf("compareVersions(%s, %s) direction = %s, want %s",
+				tt.current, tt.target, direction, tt.want)
+		}
+	}
+}
+
+func TestChecker_CheckVersion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/test/test/releases/tags/v0.1.1" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(GitHubRelease{
+			TagName: "v0.1.1",
+			Assets: []GitHubAsset{
+				{Name: "core-linux-amd64", DownloadURL: "https://example.com/core-linux-amd64"},
+				{Name: "core-darwin-arm64", DownloadURL: "https://example.com/core-darwin-arm64"},
+				{Name: "core-windows-amd64.exe", DownloadURL: "https://example.com/core-windows-amd64.exe"},
+			},
+		})
+	}))
+	defer server.Close()
+
+	checker := NewChecker(CheckerConfig{
+		GitHubAPIBaseURL: server.URL,
+		GitHubOwner:      "test",
+		GitHubRepo:       "test",
+		CurrentVersion:   "0.2.0",
+	})
+
+	info, err := checker.CheckVersion("v0.1.1")
+	if err != nil {
+		t.Fatalf("CheckVersion() failed: %v", err)
+	}
+	if info.LatestVersion != "0.1.1" {
+		t.Errorf("Expected version 0.1.1, got %s", info.LatestVersion)
+	}
+	if info.Direction != DirectionDowngrade {
+		t.Errorf("Expected downgrade, got %s", info.Direction)
+	}
+	if info.UpdateAvailable {
+		t.Error("A downgrade should not be reported as an available update")
+	}
+
+	if _, err := checker.CheckVersion("9.9.9"); err == nil {
+		t.Error("CheckVersion() should fail for a missing release")
+	}
+}
+
+// countingTransport counts the requests it forwards.
+type countingTransport struct {
+	requests int
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests++
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestChecker_Transport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"tag_name":"v1.0.0","assets":[]}`)
+	}))
+	defer server.Close()
+
+	transport := &countingTransport{}
+	checker := NewChecker(CheckerConfig{
+		APIBaseURL:       server.URL,
+		GitHubAPIBaseURL: server.URL,
+		GitHubOwner:      "test",
+		GitHubRepo:       "test",
+		CurrentVersion:   "dev",
+		Transport:        transport,
+		Timeout:          time.Second,
+	})
+
+	if checker.fetch.client.Timeout != time.Second {
+		t.Errorf("Expected configured timeout, got %v", checker.fetch.client.Timeout)
+	}
+
+	if _, err := checker.Check(); err != nil {
+		t.Fatalf("Check() failed: %v", err)
+	}
+	if transport.requests == 0 {
+		t.Error("Expected requests to go through the configured transport")
+	}
+}
+
+func TestChecker_Endpoints(t *testing.T) {
+	checker := NewChecker(CheckerConfig{
+		APIBaseURL:     "https://api.example.com/",
+		GitHubOwner:    "o",
+		GitHubRepo:     "r",
+		CurrentVersion: "1.0.0",
+		Mirrors:        []string{"https://mirror.example.com/core"},
+	})
+
+	want := []string{
+		"https://api.example.com/healthz",
+		"https://api.github.com/repos/o/r/releases/latest",
+		"https://mirror.example.com/core",
+	}
+
+	got := checker.Endpoints()
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("Endpoints() = %v, want %v", got, want)
+	}
+}
+
+func TestChecker_PrivateRepoAssets(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.URL.Path {
+		case "/repos/test/private/releases/tags/v1.1.0":
+			asset := func(id int64, name string) GitHubAsset {
+				return GitHubAsset{
+					ID:          id,
+					URL:         fmt.Sprintf("%s/repos/test/private/releases/assets/%d", server.URL, id),
+					Name:        name,
+					DownloadURL: server.URL + "/test/private/releases/download/v1.1.0/" + name,
+				}
+			}
+			json.NewEncoder(w).Encode(GitHubRelease{
+				TagName: "v1.1.0",
+				Assets: []GitHubAsset{
+					asset(1, "core-linux-amd64"),
+					asset(2, "checksums.txt"),
+					asset(3, "release.json"),
+				},
+			})
+		case "/repos/test/private/releases/assets/3":
+			if r.Header.Get("Accept") != "application/octet-stream" {
+				http.Error(w, "expected octet-stream", http.StatusBadRequest)
+				return
+			}
+			fmt.Fprint(w, `{"min_upgrade_from":"1.0.0"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	checker := NewChecker(CheckerConfig{
+		GitHubAPIBaseURL: server.URL,
+		GitHubOwner:      "test",
+		GitHubRepo:       "private",
+		GitHubToken:      "secret",
+		CurrentVersion:   "1.0.0",
+		Platform:         Platform{OS: "linux", Arch: "amd64"},
+	})
+
+	info, err := checker.CheckVersion("1.1.0")
+	if err != nil {
+		t.Fatalf("CheckVersion() failed: %v", err)
+	}
+	if info.AssetID != 1 || info.AssetAPIURL != server.URL+"/repos/test/private/releases/assets/1" {
+		t.Errorf("Expected asset 1 with its API URL, got %d %q", info.AssetID, info.AssetAPIURL)
+	}
+	if info.ChecksumAPIURL != server.URL+"/repos/test/private/releases/assets/2" {
+		t.Errorf("Expected checksum API URL, got %q", info.ChecksumAPIURL)
+	}
+	if info.MinUpgradeFrom != "1.0.0" {
+		t.Errorf("Expected release metadata fetched through the API, got %+v", info)
 	}
 }
@@ -0,0 +1,162 @@
+package update
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// metadataAssetName is the optional release asset declaring compatibility
+// metadata for its release.
+const metadataAssetName = "release.json"
+
+// ReleaseMetadata is compatibility information published with a release,
+// either by the core backend or as a release.json asset.
+type ReleaseMetadata struct {
+	// MinUpgradeFrom is the oldest version that may update directly to this
+	// release. When set it replaces the default major-version rule.
+	MinUpgradeFrom string `json:"min_upgrade_from,omitempty"`
+	// BreakingChanges lists changes users should know about before updating.
+	BreakingChanges []string `json:"breaking_changes,omitempty"`
+}
+
+// merge returns m with unset fields filled from other.
+func (m ReleaseMetadata) merge(other ReleaseMetadata) ReleaseMetadata {
+	if m.MinUpgradeFrom == "" {
+		m.MinUpgradeFrom = other.MinUpgradeFrom
+	}
+	if len(m.BreakingChanges) == 0 {
+		m.BreakingChanges = other.BreakingChanges
+	}
+	return m
+}
+
+// isCompatible reports whether the running version may move directly to
+// target. Without metadata, upgrades across a major version are
+// incompatible; a declared min_upgrade_from takes precedence over that rule.
+// Development builds are always compatible.
+func isCompatible(current, target string, meta ReleaseMetadata) bool {
+	currentVersion, err := semver.NewVersion(current)
+	if err != nil {
+		return true
+	}
+
+	targetVersion, err := semver.NewVersion(target)
+	if err != nil {
+		return false
+	}
+
+	if !targetVersion.GreaterThan(currentVersion) {
+		return true
+	}
+
+	if meta.MinUpgradeFrom != "" {
+		minVersion, err := semver.NewVersion(NormalizeVersion(meta.MinUpgradeFrom))
+		if err != nil {
+			return false
+		}
+		return !currentVersion.LessThan(minVersion)
+	}
+
+	return targetVersion.Major() <= currentVersion.Major()
+}
+
+// getReleaseMetadata returns the metadata declared by the release source,
+// completed from the release.json asset if the release has one.
+func (c *Checker) getReleaseMetadata(ctx context.Context, release *Release) (ReleaseMetadata, error) {
+	var meta ReleaseMetadata
+
+	for _, asset := range release.Assets {
+		if asset.Name != metadataAssetName {
+			continue
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, asset.DownloadURL, nil)
+		// Private repositories only serve assets through the API
+		if token := strings.TrimSpace(c.config.GitHubToken); token != "" && asset.APIURL != "" {
+			req, err = http.NewRequestWithContext(ctx, http.MethodGet, asset.APIURL, nil)
+			if err == nil {
+				req.Header.Set("Authorization", "Bearer "+token)
+				req.Header.Set("Accept", "application/octet-stream")
+			}
+		}
+		if err != nil {
+			return meta, fmt.Errorf("failed to create release metadata request: %w", err)
+		}
+
+		status, _, body, err := c.fetch.get(req)
+		if err != nil {
+			return meta, fmt.Errorf("failed to fetch release metadata: %w", err)
+		}
+		if status != http.StatusOK {
+			return meta, fmt.Errorf("release metadata returned %d: %s", status, string(body))
+		}
+		if err := json.Unmarshal(body, &meta); err != nil {
+			return meta, fmt.Errorf("invalid release metadata: %w", err)
+		}
+		break
+	}
+
+	return release.Metadata.merge(meta), nil
+}
+
+// findSteppingStone returns the newest release between current and target
+// that the running version can update to directly, or "" if there is none.
+// Installing it first lets an incompatible jump be made in steps.
+func (c *Checker) findSteppingStone(ctx context.Context, current, target string) (string, error) {
+	currentVersion, err := semver.NewVersion(current)
+	if err != nil {
+		return "", nil
+	}
+	targetVersion, err := semver.NewVersion(target)
+	if err != nil {
+		return "", nil
+	}
+
+	releases, err := c.source.ListReleases(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	type candidate struct {
+		release *Release
+		version *semver.Version
+	}
+
+	var candidates []candidate
+	for i := range releases {
+		release := &releases[i]
+		if release.Draft {
+			continue
+		}
+
+		v, err := semver.NewVersion(c.parseVersion(release.TagName))
+		if err != nil || !c.config.Channel.offers(release, v) {
+			continue
+		}
+		if v.GreaterThan(currentVersion) && v.LessThan(targetVersion) {
+			candidates = append(candidates, candidate{release, v})
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].version.GreaterThan(candidates[j].version)
+	})
+
+	for _, cand := range candidates {
+		meta, err := c.getReleaseMetadata(ctx, cand.release)
+		if err != nil {
+			return "", err
+		}
+		if isCompatible(current, cand.version.String(), meta) {
+			return cand.version.String(), nil
+		}
+	}
+
+	return "", nil
+}
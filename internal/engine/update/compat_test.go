@@ -0,0 +1,101 @@
+package update
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIsCompatible(t *testing.T) {
+	tests := []struct {
+		name    string
+		current string
+		target  string
+		meta    ReleaseMetadata
+		want    bool
+	}{
+		{"minor upgrade", "1.2.0", "1.5.0", ReleaseMetadata{}, true},
+		{"major upgrade", "1.9.0", "2.0.0", ReleaseMetadata{}, false},
+		{"downgrade across major", "2.0.0", "1.9.9", ReleaseMetadata{}, true},
+		{"dev build", "dev", "3.0.0", ReleaseMetadata{}, true},
+		{"unparseable target", "1.0.0", "latest", ReleaseMetadata{}, false},
+		{"min_upgrade_from met", "1.8.0", "2.0.0", ReleaseMetadata{MinUpgradeFrom: "v1.8.0"}, true},
+		{"min_upgrade_from not met", "1.2.0", "1.9.0", ReleaseMetadata{MinUpgradeFrom: "1.5.0"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isCompatible(tt.current, tt.target, tt.meta); got != tt.want {
+				t.Errorf("isCompatible(%s, %s) = %v, want %v", tt.current, tt.target, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChecker_Check_IncompatibleSuggestsSteppingStone(t *testing.T) {
+	var server *httptest.Server
+	release := func(tag string, meta *ReleaseMetadata) GitHubRelease {
+		r := GitHubRelease{TagName: tag}
+		if meta != nil {
+			r.Assets = append(r.Assets, GitHubAsset{
+				Name:        metadataAssetName,
+				DownloadURL: server.URL + "/meta/" + tag,
+			})
+		}
+		return r
+	}
+
+	metas := map[string]ReleaseMetadata{
+		"v3.0.0": {MinUpgradeFrom: "2.4.0", BreakingChanges: []string{"Config moved to ~/.config/core"}},
+		"v2.4.0": {MinUpgradeFrom: "1.0.0"},
+	}
+
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/o/r/releases/latest":
+			meta := metas["v3.0.0"]
+			json.NewEncoder(w).Encode(release("v3.0.0", &meta))
+		case "/repos/o/r/releases":
+			m3, m24 := metas["v3.0.0"], metas["v2.4.0"]
+			json.NewEncoder(w).Encode([]GitHubRelease{
+				release("v3.0.0", &m3),
+				release("v2.5.0", nil),
+				release("v2.4.0", &m24),
+				release("v1.3.0", nil),
+			})
+		case "/meta/v3.0.0", "/meta/v2.4.0":
+			json.NewEncoder(w).Encode(metas[r.URL.Path[len("/meta/"):]])
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	checker := NewChecker(CheckerConfig{
+		APIBaseURL:       server.URL,
+		GitHubAPIBaseURL: server.URL,
+		GitHubOwner:      "o",
+		GitHubRepo:       "r",
+		CurrentVersion:   "1.2.0",
+	})
+
+	info, err := checker.Check()
+	if err != nil {
+		t.Fatalf("Check() failed: %v", err)
+	}
+
+	if info.Compatible {
+		t.Error("Expected 1.2.0 -> 3.0.0 to be incompatible")
+	}
+	if info.MinUpgradeFrom != "2.4.0" {
+		t.Errorf("Expected min_upgrade_from 2.4.0, got %q", info.MinUpgradeFrom)
+	}
+	if len(info.BreakingChanges) != 1 {
+		t.Errorf("Expected breaking changes from release metadata, got %v", info.BreakingChanges)
+	}
+	// 2.5.0 is a major bump without metadata; 2.4.0 allows updates from 1.x
+	if info.SteppingStone != "2.4.0" {
+		t.Errorf("Expected stepping stone 2.4.0, got %q", info.SteppingStone)
+	}
+}
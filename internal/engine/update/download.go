@@ -0,0 +1,374 @@
+package update
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultMaxRetries     = 3
+	defaultRetryBaseDelay = 500 * time.Millisecond
+	maxRetryDelay         = 30 * time.Second
+)
+
+// partialMeta records the validators of a partially downloaded file so a
+// later attempt only resumes if the remote file is unchanged.
+type partialMeta struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// retryableError marks a download failure worth retrying: network errors,
+// interrupted transfers, 429 and 5xx responses.
+type retryableError struct {
+	err error
+}
+
+func (e *retryableError) Error() string { return e.err.Error() }
+func (e *retryableError) Unwrap() error { return e.err }
+
+func isRetryable(err error) bool {
+	var re *retryableError
+	return errors.As(err, &re)
+}
+
+// download fetches the asset into a persistent partial file, trying each
+// download mirror in turn. The completed file path is returned; the caller
+// removes it once the update is applied. Cancelling ctx aborts the transfer
+// but keeps the partial file, so the next run resumes where it stopped.
+func (u *Updater) download(ctx context.Context) (string, error) {
+	u.emit(UpdateProgress{
+		Stage: StageDownloading,
+	})
+
+	partPath, err := u.partialPath()
+	if err != nil {
+		return "", err
+	}
+
+	var lastErr error
+	for i, candidate := range u.downloadCandidates() {
+		if i > 0 {
+			u.emit(UpdateProgress{
+				Stage:  StageFallback,
+				Mirror: mirrorName(candidate),
+				Error:  lastErr,
+			})
+		}
+
+		lastErr = u.downloadFrom(ctx, candidate, partPath)
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		u.mirrors.record(candidate, lastErr)
+
+		if lastErr == nil {
+			os.Remove(partPath + ".json")
+
+			// Make file executable
+			if err := os.Chmod(partPath, 0755); err != nil {
+				os.Remove(partPath)
+				return "", fmt.Errorf("failed to make file executable: %w", err)
+			}
+			return partPath, nil
+		}
+	}
+
+	return "", lastErr
+}
+
+// downloadFrom downloads rawURL into partPath, resuming with HTTP Range
+// requests and retrying transient failures with exponential backoff.
+func (u *Updater) downloadFrom(ctx context.Context, rawURL, partPath string) error {
+	maxRetries := u.config.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = defaultMaxRetries
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			u.emit(UpdateProgress{
+				Stage:   StageRetrying,
+				Attempt: attempt,
+				Mirror:  mirrorName(rawURL),
+				Error:   lastErr,
+			})
+
+			timer := time.NewTimer(u.retryDelay(attempt))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+
+		lastErr = u.downloadAttempt(ctx, rawURL, partPath)
+		if lastErr == nil || ctx.Err() != nil {
+			return lastErr
+		}
+
+		if !isRetryable(lastErr) {
+			removePartial(partPath)
+			return lastErr
+		}
+	}
+
+	// Keep the partial file so the next run can resume
+	return fmt.Errorf("download failed after %d attempts: %w", maxRetries+1, lastErr)
+}
+
+// downloadAttempt performs a single request, resuming the partial file when
+// its validators still match the remote file.
+func (u *Updater) downloadAttempt(ctx context.Context, rawURL, partPath string) error {
+	meta := readPartialMeta(partPath)
+	mirror := mirrorName(rawURL)
+
+	var offset int64
+	if info, err := os.Stat(partPath); err == nil && meta.URL == rawURL &&
+		(meta.ETag != "" || meta.LastModified != "") {
+		offset = info.Size()
+	}
+
+	req, err := u.newRequest(ctx, rawURL)
+	if err != nil {
+		return fmt.Errorf("failed to create download request: %w", err)
+	}
+
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		// If-Range makes the server send the full file if it changed
+		if meta.ETag != "" {
+			req.Header.Set("If-Range", meta.ETag)
+		} else {
+			req.Header.Set("If-Range", meta.LastModified)
+		}
+	}
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return &retryableError{fmt.Errorf("failed to download: %w", err)}
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	totalSize := resp.ContentLength
+
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		start, total, ok := parseContentRange(resp.Header.Get("Content-Range"))
+		if !ok || start != offset || !validatorsMatch(meta, resp.Header) {
+			removePartial(partPath)
+			return &retryableError{fmt.Errorf("remote file changed during download")}
+		}
+		flags = os.O_WRONLY | os.O_APPEND
+		totalSize = total
+
+		u.emit(UpdateProgress{
+			Stage:      StageResuming,
+			BytesDone:  offset,
+			BytesTotal: totalSize,
+			Percent:    percentOf(offset, totalSize),
+			Mirror:     mirror,
+		})
+
+	case resp.StatusCode == http.StatusOK:
+		// Fresh download, or the server ignored the range
+		offset = 0
+
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		removePartial(partPath)
+		return &retryableError{fmt.Errorf("download returned status %d", resp.StatusCode)}
+
+	default:
+		err := fmt.Errorf("download returned status %d", resp.StatusCode)
+		if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+			return &retryableError{err}
+		}
+		return err
+	}
+
+	// Record validators before writing so an interrupted transfer can resume
+	if err := writePartialMeta(partPath, partialMeta{
+		URL:          rawURL,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}); err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(partPath, flags, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer out.Close()
+
+	// Create progress reader
+	reader := &progressReader{
+		ctx:    ctx,
+		reader: resp.Body,
+		read:   offset,
+		update: func(current int64) {
+			u.emit(UpdateProgress{
+				Stage:      StageDownloading,
+				Percent:    percentOf(current, totalSize),
+				BytesTotal: totalSize,
+				BytesDone:  current,
+				Mirror:     mirror,
+			})
+		},
+	}
+
+	if _, err := io.Copy(out, reader); err != nil {
+		return &retryableError{fmt.Errorf("failed to write file: %w", err)}
+	}
+
+	if totalSize > 0 && reader.read != totalSize {
+		return &retryableError{fmt.Errorf("download incomplete: got %d of %d bytes", reader.read, totalSize)}
+	}
+
+	return nil
+}
+
+// partialPath returns the persistent partial file for the asset, under the
+// state directory when configured. Mirrors share it; resuming only happens
+// from the mirror that wrote it.
+func (u *Updater) partialPath() (string, error) {
+	dir := os.TempDir()
+	if u.config.StateDir != "" {
+		dir = filepath.Join(u.config.StateDir, "downloads")
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return "", fmt.Errorf("failed to create download directory: %w", err)
+		}
+	}
+
+	sum := sha256.Sum256([]byte(u.config.DownloadURL))
+	return filepath.Join(dir, fmt.Sprintf("core-update-%x.part", sum[:8])), nil
+}
+
+// retryDelay returns the exponential backoff for an attempt, with up to 50%
+// random jitter so concurrent clients do not retry in lockstep.
+func (u *Updater) retryDelay(attempt int) time.Duration {
+	base := u.config.RetryBaseDelay
+	if base <= 0 {
+		base = defaultRetryBaseDelay
+	}
+
+	delay := base << (attempt - 1)
+	if delay <= 0 || delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+
+	return delay + rand.N(delay/2+1)
+}
+
+// validatorsMatch reports whether a 206 response carries the same validators
+// the partial file was downloaded with.
+func validatorsMatch(meta partialMeta, header http.Header) bool {
+	if etag := header.Get("ETag"); etag != "" && meta.ETag != "" && etag != meta.ETag {
+		return false
+	}
+	if lm := header.Get("Last-Modified"); lm != "" && meta.LastModified != "" && lm != meta.LastModified {
+		return false
+	}
+	return true
+}
+
+// parseContentRange parses "bytes start-end/total". A total of "*" is
+// reported as -1.
+func parseContentRange(value string) (start, total int64, ok bool) {
+	value, found := strings.CutPrefix(value, "bytes ")
+	if !found {
+		return 0, 0, false
+	}
+
+	span, size, found := strings.Cut(value, "/")
+	if !found {
+		return 0, 0, false
+	}
+
+	first, _, found := strings.Cut(span, "-")
+	if !found {
+		return 0, 0, false
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	total = -1
+	if size != "*" {
+		if total, err = strconv.ParseInt(size, 10, 64); err != nil {
+			return 0, 0, false
+		}
+	}
+
+	return start, total, true
+}
+
+func percentOf(done, total int64) int {
+	if total <= 0 {
+		return 0
+	}
+	return int((done * 100) / total)
+}
+
+func readPartialMeta(partPath string) partialMeta {
+	var meta partialMeta
+	data, err := os.ReadFile(partPath + ".json")
+	if err == nil {
+		_ = json.Unmarshal(data, &meta)
+	}
+	return meta
+}
+
+func writePartialMeta(partPath string, meta partialMeta) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("failed to encode download metadata: %w", err)
+	}
+	if err := os.WriteFile(partPath+".json", data, 0644); err != nil {
+		return fmt.Errorf("failed to write download metadata: %w", err)
+	}
+	return nil
+}
+
+func removePartial(partPath string) {
+	os.Remove(partPath)
+	os.Remove(partPath + ".json")
+}
+
+// progressReader wraps a reader, reports progress and stops once its
+// context is done.
+type progressReader struct {
+	ctx    context.Context
+	reader io.Reader
+	read   int64
+	update func(int64)
+}
+
+func (pr *progressReader) Read(p []byte) (int, error) {
+	if err := pr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := pr.reader.Read(p)
+	if n > 0 {
+		pr.read += int64(n)
+		pr.update(pr.read)
+	}
+	return n, err
+}
@@ -0,0 +1,238 @@
+package update
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestUpdater_Download_Resume(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 1000)
+	var gotRange string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotRange = r.Header.Get("Range")
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "core", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+
+	updater := NewUpdater(UpdaterConfig{
+		DownloadURL: server.URL,
+		StateDir:    t.TempDir(),
+	})
+
+	// Simulate an interrupted earlier attempt
+	partPath, _ := updater.partialPath()
+	os.WriteFile(partPath, content[:4000], 0644)
+	writePartialMeta(partPath, partialMeta{URL: server.URL, ETag: `"v1"`})
+
+	resumed := false
+	updater.SetProgressCallback(func(up UpdateProgress) {
+		if up.Stage == "resuming" && up.BytesDone == 4000 {
+			resumed = true
+		}
+	})
+
+	path, err := updater.download(context.Background())
+	if err != nil {
+		t.Fatalf("download() failed: %v", err)
+	}
+	defer os.Remove(path)
+
+	if gotRange != "bytes=4000-" {
+		t.Errorf("Expected range request from 4000, got %q", gotRange)
+	}
+	if !resumed {
+		t.Error("Expected a resuming progress event")
+	}
+
+	data, _ := os.ReadFile(path)
+	if !bytes.Equal(data, content) {
+		t.Error("Resumed download content mismatch")
+	}
+}
+
+func TestUpdater_Download_RestartsWhenRemoteChanged(t *testing.T) {
+	content := []byte("completely new release content")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v2"`)
+		http.ServeContent(w, r, "core", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+
+	updater := NewUpdater(UpdaterConfig{
+		DownloadURL: server.URL,
+		StateDir:    t.TempDir(),
+	})
+
+	partPath, _ := updater.partialPath()
+	os.WriteFile(partPath, []byte("stale bytes"), 0644)
+	writePartialMeta(partPath, partialMeta{URL: server.URL, ETag: `"v1"`})
+
+	path, err := updater.download(context.Background())
+	if err != nil {
+		t.Fatalf("download() failed: %v", err)
+	}
+	defer os.Remove(path)
+
+	data, _ := os.ReadFile(path)
+	if !bytes.Equal(data, content) {
+		t.Errorf("Expected fresh content, got %q", data)
+	}
+}
+
+func TestUpdater_Download_RetriesServerErrors(t *testing.T) {
+	content := []byte("binary after retries")
+	var requests int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) <= 2 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write(content)
+	}))
+	defer server.Close()
+
+	updater := NewUpdater(UpdaterConfig{
+		DownloadURL:    server.URL,
+		RetryBaseDelay: time.Millisecond,
+	})
+
+	retries := 0
+	updater.SetProgressCallback(func(up UpdateProgress) {
+		if up.Stage == "retrying" {
+			retries++
+			if up.Attempt != retries || up.Error == nil {
+				t.Errorf("Unexpected retry event: %+v", up)
+			}
+		}
+	})
+
+	path, err := updater.download(context.Background())
+	if err != nil {
+		t.Fatalf("download() failed: %v", err)
+	}
+	defer os.Remove(path)
+
+	if retries != 2 {
+		t.Errorf("Expected 2 retries, got %d", retries)
+	}
+}
+
+func TestUpdater_Download_GivesUp(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	updater := NewUpdater(UpdaterConfig{
+		DownloadURL:    server.URL,
+		MaxRetries:     2,
+		RetryBaseDelay: time.Millisecond,
+	})
+
+	if _, err := updater.download(context.Background()); err == nil {
+		t.Fatal("download() should fail after exhausting retries")
+	}
+	if requests != 3 {
+		t.Errorf("Expected 3 attempts, got %d", requests)
+	}
+}
+
+func TestUpdater_ApplyContext_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Length", "100000")
+		w.Write(bytes.Repeat([]byte("x"), 1000))
+		w.(http.Flusher).Flush()
+		// Stall mid-transfer until the client gives up
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	target := t.TempDir() + "/core"
+	os.WriteFile(target, []byte("original"), 0755)
+
+	updater := NewUpdater(UpdaterConfig{
+		DownloadURL:    server.URL,
+		TargetPath:     target,
+		ChecksumPolicy: ChecksumSkip,
+		StateDir:       t.TempDir(),
+		RetryBaseDelay: time.Millisecond,
+	})
+
+	// Cancel once the first bytes are on disk
+	updater.SetProgressCallback(func(p UpdateProgress) {
+		if p.Stage == StageDownloading && p.BytesDone >= 1000 {
+			cancel()
+		}
+	})
+
+	err := updater.ApplyContext(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+
+	// The next run resumes instead of starting over
+	partPath, _ := updater.partialPath()
+	if info, err := os.Stat(partPath); err != nil || info.Size() != 1000 {
+		t.Errorf("Expected the 1000 downloaded bytes to be kept after cancellation, got %v", err)
+	}
+	if meta := readPartialMeta(partPath); meta.ETag != `"v1"` {
+		t.Errorf("Expected the partial download's validators to be kept, got %+v", meta)
+	}
+	if data, _ := os.ReadFile(target); string(data) != "original" {
+		t.Error("Target binary must be untouched after cancellation")
+	}
+}
+
+func TestChecker_CheckContext_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	checker := NewChecker(CheckerConfig{
+		APIBaseURL:       "http://127.0.0.1:0",
+		GitHubAPIBaseURL: "http://127.0.0.1:0",
+		GitHubOwner:      "Tfc538",
+		GitHubRepo:       "core-cli",
+		CurrentVersion:   "1.0.0",
+	})
+
+	if _, err := checker.CheckContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestParseContentRange(t *testing.T) {
+	tests := []struct {
+		value     string
+		start     int64
+		total     int64
+		wantValid bool
+	}{
+		{"bytes 100-199/200", 100, 200, true},
+		{"bytes 0-99/*", 0, -1, true},
+		{"items 0-1/2", 0, 0, false},
+		{"bytes garbage", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		start, total, ok := parseContentRange(tt.value)
+		if ok != tt.wantValid || start != tt.start || total != tt.total {
+			t.Errorf("parseContentRange(%q) = %d, %d, %v", tt.value, start, total, ok)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package update
+
+import (
+	"context"
+	"errors"
+	"net/url"
+)
+
+// errorCodes maps the package's errors to the stable codes reported in
+// machine-readable output. More specific errors come first.
+var errorCodes = []struct {
+	err  error
+	code string
+}{
+	{context.Canceled, "cancelled"},
+	{context.DeadlineExceeded, "timeout"},
+	{ErrUpdateLocked, "update_locked"},
+	{ErrPreflightFailed, "preflight_failed"},
+	{ErrRateLimited, "rate_limited"},
+	{ErrUnauthorized, "unauthorized"},
+	{ErrReleaseNotFound, "release_not_found"},
+	{ErrRepoNotFound, "repo_not_found"},
+	{ErrNetwork, "network"},
+	{ErrInsecureManifest, "insecure_manifest"},
+	{ErrNoMatchingAsset, "no_matching_asset"},
+	{ErrInvalidBundle, "invalid_bundle"},
+	{ErrChecksumMismatch, "checksum_mismatch"},
+	{ErrChecksumNotFound, "checksum_not_found"},
+	{ErrChecksumUnavailable, "checksum_unavailable"},
+	{ErrSignatureInvalid, "signature_invalid"},
+	{ErrSignatureMissing, "signature_missing"},
+	{ErrBinaryNotInArchive, "binary_not_in_archive"},
+	{ErrInvalidBinary, "invalid_binary"},
+	{ErrSelfTestFailed, "self_test_failed"},
+	{ErrNoBackup, "no_backup"},
+}
+
+// ErrorCode classifies err as a stable, machine-readable code such as
+// "checksum_mismatch" or "rate_limited", for scripts that must not parse
+// messages. Failed requests are "network"; anything else is "error". A nil
+// error has no code.
+func ErrorCode(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	for _, c := range errorCodes {
+		if errors.Is(err, c.err) {
+			return c.code
+		}
+	}
+
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return "network"
+	}
+	return "error"
+}
@@ -0,0 +1,30 @@
+package update
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+)
+
+func TestErrorCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{nil, ""},
+		{fmt.Errorf("checksum verification failed: %w", ErrChecksumMismatch), "checksum_mismatch"},
+		{fmt.Errorf("update cancelled: %w", context.Canceled), "cancelled"},
+		{&LockedError{LockInfo: LockInfo{PID: 42}}, "update_locked"},
+		{&APIError{Host: "GitHub", Status: 429, Err: ErrRateLimited}, "rate_limited"},
+		{&url.Error{Op: "Get", URL: "https://example.com", Err: errors.New("connection refused")}, "network"},
+		{errors.New("something else"), "error"},
+	}
+
+	for _, tt := range tests {
+		if got := ErrorCode(tt.err); got != tt.want {
+			t.Errorf("ErrorCode(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
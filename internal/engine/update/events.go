@@ -0,0 +1,66 @@
+package update
+
+import (
+	"sync"
+	"time"
+)
+
+// EventBus delivers update progress to any number of subscribers, such as a
+// terminal renderer, a JSON emitter and a log, without them wrapping each
+// other's callbacks. Delivery is synchronous and in subscription order, so
+// output stays in step with the update.
+type EventBus struct {
+	mu     sync.Mutex
+	nextID int
+	subs   []subscriber
+}
+
+type subscriber struct {
+	id int
+	cb ProgressCallback
+}
+
+// NewEventBus creates an event bus without subscribers.
+func NewEventBus() *EventBus {
+	return &EventBus{}
+}
+
+// Subscribe registers cb for every event published from now on. The
+// returned function removes it again and is safe to call more than once,
+// including from within cb.
+func (b *EventBus) Subscribe(cb ProgressCallback) (unsubscribe func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	id := b.nextID
+	b.subs = append(b.subs, subscriber{id: id, cb: cb})
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for i, sub := range b.subs {
+			if sub.id == id {
+				b.subs = append(b.subs[:i:i], b.subs[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Publish stamps the event with the current time, unless it has one, and
+// hands it to every subscriber.
+func (b *EventBus) Publish(progress UpdateProgress) {
+	if progress.Time.IsZero() {
+		progress.Time = time.Now()
+	}
+
+	// Subscribers may unsubscribe while the event is delivered
+	b.mu.Lock()
+	subs := append([]subscriber(nil), b.subs...)
+	b.mu.Unlock()
+
+	for _, sub := range subs {
+		sub.cb(progress)
+	}
+}
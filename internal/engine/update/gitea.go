@@ -0,0 +1,32 @@
+package update
+
+import (
+	"net/http"
+	"strings"
+)
+
+// GiteaSource reads releases from a Gitea or Forgejo instance. Their
+// release API mirrors GitHub's, so only the base URL, authentication and
+// paging differ.
+type GiteaSource struct {
+	*GitHubSource
+}
+
+// newGiteaSource creates a source for the configured Gitea repository.
+// SourceURL is the instance API root, e.g. https://gitea.example.com/api/v1.
+func newGiteaSource(config CheckerConfig, f *fetcher) *GiteaSource {
+	header := http.Header{}
+	if token := strings.TrimSpace(config.SourceToken); token != "" {
+		header.Set("Authorization", "token "+token)
+	}
+
+	return &GiteaSource{&GitHubSource{
+		name:      "Gitea",
+		baseURL:   strings.TrimRight(config.SourceURL, "/"),
+		owner:     config.GitHubOwner,
+		repo:      config.GitHubRepo,
+		listQuery: "releases?limit=50",
+		header:    header,
+		fetch:     f,
+	}}
+}
@@ -0,0 +1,128 @@
+package update
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// GitHubRelease represents a GitHub release response.
+type GitHubRelease struct {
+	TagName    string        `json:"tag_name"`
+	Body       string        `json:"body"`
+	Draft      bool          `json:"draft"`
+	Prerelease bool          `json:"prerelease"`
+	Assets     []GitHubAsset `json:"assets"`
+}
+
+// GitHubAsset represents a release asset. DownloadURL only works for
+// public repositories; private assets are downloaded from the API URL.
+type GitHubAsset struct {
+	ID          int64  `json:"id"`
+	URL         string `json:"url"` // API URL of the asset
+	Name        string `json:"name"`
+	DownloadURL string `json:"browser_download_url"`
+}
+
+// release converts the API response into a Release.
+func (r GitHubRelease) release() Release {
+	release := Release{
+		TagName:    r.TagName,
+		Body:       r.Body,
+		Draft:      r.Draft,
+		Prerelease: r.Prerelease,
+	}
+	for _, asset := range r.Assets {
+		release.Assets = append(release.Assets, Asset{
+			Name:        asset.Name,
+			DownloadURL: asset.DownloadURL,
+			ID:          asset.ID,
+			APIURL:      asset.URL,
+		})
+	}
+	return release
+}
+
+// GitHubSource reads releases from the GitHub Releases API.
+type GitHubSource struct {
+	name      string
+	baseURL   string
+	owner     string
+	repo      string
+	listQuery string
+	header    http.Header
+	fetch     *fetcher
+}
+
+// newGitHubSource creates a source for the configured GitHub repository.
+func newGitHubSource(config CheckerConfig, f *fetcher) *GitHubSource {
+	header := http.Header{}
+	if token := strings.TrimSpace(config.GitHubToken); token != "" {
+		header.Set("Authorization", "Bearer "+token)
+		header.Set("Accept", "application/vnd.github+json")
+	}
+
+	return &GitHubSource{
+		name:      "GitHub",
+		baseURL:   strings.TrimRight(config.GitHubAPIBaseURL, "/"),
+		owner:     config.GitHubOwner,
+		repo:      config.GitHubRepo,
+		listQuery: "releases?per_page=100",
+		header:    header,
+		fetch:     f,
+	}
+}
+
+// Name implements ReleaseSource.
+func (s *GitHubSource) Name() string {
+	return s.name
+}
+
+// LatestRelease implements ReleaseSource using /releases/latest, which
+// never returns drafts or prereleases.
+func (s *GitHubSource) LatestRelease(ctx context.Context) (*Release, error) {
+	return s.getRelease(ctx, s.repoURL("releases/latest"))
+}
+
+// ReleaseByTag implements ReleaseSource.
+func (s *GitHubSource) ReleaseByTag(ctx context.Context, tag string) (*Release, error) {
+	release, err := s.getRelease(ctx, s.repoURL("releases/tags/"+url.PathEscape(tag)))
+	if err != nil {
+		return nil, s.fetch.releaseNotFound(ctx, s.name, fmt.Sprintf("%s/repos/%s/%s", s.baseURL, s.owner, s.repo), s.header, err)
+	}
+	return release, nil
+}
+
+// ListReleases implements ReleaseSource.
+func (s *GitHubSource) ListReleases(ctx context.Context) ([]Release, error) {
+	var payload []GitHubRelease
+	if err := s.fetch.getJSON(ctx, s.name, s.repoURL(s.listQuery), s.header, &payload); err != nil {
+		return nil, err
+	}
+
+	releases := make([]Release, 0, len(payload))
+	for _, r := range payload {
+		releases = append(releases, r.release())
+	}
+	return releases, nil
+}
+
+func (s *GitHubSource) endpoint() string {
+	return s.repoURL("releases/latest")
+}
+
+func (s *GitHubSource) getRelease(ctx context.Context, url string) (*Release, error) {
+	var payload GitHubRelease
+	if err := s.fetch.getJSON(ctx, s.name, url, s.header, &payload); err != nil {
+		return nil, err
+	}
+
+	release := payload.release()
+	return &release, nil
+}
+
+func (s *GitHubSource) repoURL(path string) string {
+	return fmt.Sprintf("%s/repos/%s/%s/%s", s.baseURL, s.owner, s.repo, path)
+}
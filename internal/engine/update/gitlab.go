@@ -0,0 +1,124 @@
+package update
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const defaultGitLabAPIBaseURL = "https://gitlab.com/api/v4"
+
+// gitLabRelease represents a GitLab release response.
+type gitLabRelease struct {
+	TagName         string `json:"tag_name"`
+	Description     string `json:"description"`
+	UpcomingRelease bool   `json:"upcoming_release"`
+	Assets          struct {
+		Links []gitLabLink `json:"links"`
+	} `json:"assets"`
+}
+
+// gitLabLink is a release asset link. Binaries are attached as links to
+// package registry or job artifact files.
+type gitLabLink struct {
+	Name           string `json:"name"`
+	URL            string `json:"url"`
+	DirectAssetURL string `json:"direct_asset_url"`
+}
+
+// release converts the API response into a Release. GitLab has no
+// prerelease flag; channels are derived from the version as usual.
+func (r gitLabRelease) release() Release {
+	release := Release{
+		TagName: r.TagName,
+		Body:    r.Description,
+		Draft:   r.UpcomingRelease,
+	}
+	for _, link := range r.Assets.Links {
+		downloadURL := link.DirectAssetURL
+		if downloadURL == "" {
+			downloadURL = link.URL
+		}
+		release.Assets = append(release.Assets, Asset{Name: link.Name, DownloadURL: downloadURL})
+	}
+	return release
+}
+
+// GitLabSource reads releases from the GitLab Releases API.
+type GitLabSource struct {
+	baseURL string
+	project string
+	header  http.Header
+	fetch   *fetcher
+}
+
+// newGitLabSource creates a source for the configured GitLab project
+// (owner/repo, including any subgroups in the owner).
+func newGitLabSource(config CheckerConfig, f *fetcher) *GitLabSource {
+	baseURL := strings.TrimRight(config.SourceURL, "/")
+	if baseURL == "" {
+		baseURL = defaultGitLabAPIBaseURL
+	}
+
+	header := http.Header{}
+	if token := strings.TrimSpace(config.SourceToken); token != "" {
+		header.Set("PRIVATE-TOKEN", token)
+	}
+
+	return &GitLabSource{
+		baseURL: baseURL,
+		project: url.PathEscape(config.GitHubOwner + "/" + config.GitHubRepo),
+		header:  header,
+		fetch:   f,
+	}
+}
+
+// Name implements ReleaseSource.
+func (s *GitLabSource) Name() string {
+	return "GitLab"
+}
+
+// LatestRelease implements ReleaseSource. GitLab's latest permalink may
+// point at a prerelease, so the newest stable release is selected locally.
+func (s *GitLabSource) LatestRelease(ctx context.Context) (*Release, error) {
+	releases, err := s.ListReleases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return highestRelease(releases, ChannelStable)
+}
+
+// ReleaseByTag implements ReleaseSource.
+func (s *GitLabSource) ReleaseByTag(ctx context.Context, tag string) (*Release, error) {
+	var payload gitLabRelease
+	if err := s.fetch.getJSON(ctx, s.Name(), s.projectURL("releases/"+url.PathEscape(tag)), s.header, &payload); err != nil {
+		return nil, s.fetch.releaseNotFound(ctx, s.Name(), fmt.Sprintf("%s/projects/%s", s.baseURL, s.project), s.header, err)
+	}
+
+	release := payload.release()
+	return &release, nil
+}
+
+// ListReleases implements ReleaseSource.
+func (s *GitLabSource) ListReleases(ctx context.Context) ([]Release, error) {
+	var payload []gitLabRelease
+	if err := s.fetch.getJSON(ctx, s.Name(), s.projectURL("releases?per_page=100"), s.header, &payload); err != nil {
+		return nil, err
+	}
+
+	releases := make([]Release, 0, len(payload))
+	for _, r := range payload {
+		releases = append(releases, r.release())
+	}
+	return releases, nil
+}
+
+func (s *GitLabSource) endpoint() string {
+	return s.projectURL("releases")
+}
+
+func (s *GitLabSource) projectURL(path string) string {
+	return fmt.Sprintf("%s/projects/%s/%s", s.baseURL, s.project, path)
+}
@@ -0,0 +1,287 @@
+package update
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/minio/selfupdate"
+)
+
+const (
+	historyFile        = "history.json"
+	versionsDir        = "versions"
+	defaultKeepBackups = 3
+)
+
+// ErrNoBackup is returned when no retained binary matches a rollback request.
+var ErrNoBackup = errors.New("no retained binary available")
+
+// BackupEntry describes a previously installed binary kept for rollback.
+type BackupEntry struct {
+	Version     string    `json:"version"`
+	Commit      string    `json:"commit,omitempty"`
+	InstalledAt time.Time `json:"installed_at"`
+	RetainedAt  time.Time `json:"retained_at"`
+	Path        string    `json:"path"`
+}
+
+// History manages the binaries retained in the update state directory.
+// Each binary lives in versions/<version>/, and history.json records the
+// entries newest first.
+type History struct {
+	dir  string
+	keep int
+}
+
+// NewHistory creates a history rooted at dir that keeps at most keep
+// binaries. A non-positive keep uses the default of 3.
+func NewHistory(dir string, keep int) *History {
+	if keep <= 0 {
+		keep = defaultKeepBackups
+	}
+	return &History{dir: dir, keep: keep}
+}
+
+// List returns the retained binaries, newest first.
+func (h *History) List() ([]BackupEntry, error) {
+	data, err := os.ReadFile(filepath.Join(h.dir, historyFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read update history: %w", err)
+	}
+
+	var entries []BackupEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("invalid update history: %w", err)
+	}
+
+	return entries, nil
+}
+
+// Retain moves the binary at path into the state directory and records it.
+// Entries beyond the configured limit are pruned, oldest first.
+func (h *History) Retain(path string, entry BackupEntry) error {
+	if entry.Version == "" {
+		entry.Version = "unknown"
+	}
+
+	entries, err := h.List()
+	if err != nil {
+		return err
+	}
+
+	entry.Path = h.binaryPath(entry.Version)
+	if err := os.MkdirAll(filepath.Dir(entry.Path), 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	if entry.RetainedAt.IsZero() {
+		entry.RetainedAt = time.Now().UTC()
+	}
+	if err := moveFile(path, entry.Path); err != nil {
+		return fmt.Errorf("failed to retain binary: %w", err)
+	}
+
+	// Replace any older copy of the same version
+	kept := []BackupEntry{entry}
+	for _, existing := range entries {
+		if existing.Version != entry.Version {
+			kept = append(kept, existing)
+		}
+	}
+
+	sort.SliceStable(kept, func(i, j int) bool {
+		return kept[i].RetainedAt.After(kept[j].RetainedAt)
+	})
+
+	for _, pruned := range kept[min(len(kept), h.keep):] {
+		os.RemoveAll(filepath.Dir(pruned.Path))
+	}
+	kept = kept[:min(len(kept), h.keep)]
+
+	return h.save(kept)
+}
+
+// binaryPath returns where the binary of version is retained.
+func (h *History) binaryPath(version string) string {
+	return filepath.Join(h.dir, versionsDir, version, binaryName())
+}
+
+// Find returns the retained entry for version, or the most recent entry when
+// version is empty.
+func (h *History) Find(version string) (BackupEntry, error) {
+	entries, err := h.List()
+	if err != nil {
+		return BackupEntry{}, err
+	}
+
+	for _, entry := range entries {
+		if version == "" || entry.Version == version {
+			return entry, nil
+		}
+	}
+
+	if version == "" {
+		return BackupEntry{}, ErrNoBackup
+	}
+	return BackupEntry{}, fmt.Errorf("%w for version %s", ErrNoBackup, version)
+}
+
+// Restore atomically replaces targetPath with the retained binary for
+// version (the most recent one when empty). The binary being replaced is
+// retained in turn as current, so a rollback can itself be undone. It
+// fails with ErrUpdateLocked while an update is in progress.
+func (h *History) Restore(version, targetPath string, current BackupEntry) (BackupEntry, error) {
+	lock, err := AcquireLock(h.dir)
+	if err != nil {
+		return BackupEntry{}, err
+	}
+	defer lock.Release()
+
+	entry, err := h.Find(version)
+	if err != nil {
+		return BackupEntry{}, err
+	}
+
+	backup, err := os.Open(entry.Path)
+	if err != nil {
+		return BackupEntry{}, fmt.Errorf("failed to open retained binary: %w", err)
+	}
+	defer backup.Close()
+
+	if current.InstalledAt.IsZero() {
+		current.InstalledAt = modTime(targetPath)
+	}
+
+	oldPath := oldSavePath(targetPath)
+	if err := selfupdate.Apply(backup, selfupdate.Options{
+		TargetPath:  targetPath,
+		OldSavePath: oldPath,
+	}); err != nil {
+		if rerr := selfupdate.RollbackError(err); rerr != nil {
+			return BackupEntry{}, fmt.Errorf("failed to restore binary and roll back: %w (rollback: %v)", err, rerr)
+		}
+		return BackupEntry{}, fmt.Errorf("failed to restore binary: %w", err)
+	}
+	backup.Close()
+
+	// The restored version is installed again; drop its backup
+	if err := h.remove(entry.Version); err != nil {
+		return entry, err
+	}
+
+	if err := h.Retain(oldPath, current); err != nil {
+		return entry, err
+	}
+
+	return entry, nil
+}
+
+// remove deletes the retained binary for version.
+func (h *History) remove(version string) error {
+	entries, err := h.List()
+	if err != nil {
+		return err
+	}
+
+	kept := entries[:0]
+	for _, entry := range entries {
+		if entry.Version == version {
+			os.RemoveAll(filepath.Dir(entry.Path))
+			continue
+		}
+		kept = append(kept, entry)
+	}
+
+	return h.save(kept)
+}
+
+// save writes the history file atomically.
+func (h *History) save(entries []BackupEntry) error {
+	if err := os.MkdirAll(h.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode update history: %w", err)
+	}
+
+	tmp := filepath.Join(h.dir, historyFile+".tmp")
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write update history: %w", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(h.dir, historyFile)); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write update history: %w", err)
+	}
+
+	return nil
+}
+
+// oldSavePath returns where selfupdate keeps the replaced binary. It must be
+// next to the target so the swap stays a same-filesystem rename.
+func oldSavePath(targetPath string) string {
+	return filepath.Join(filepath.Dir(targetPath), "."+filepath.Base(targetPath)+".old")
+}
+
+// binaryName returns the file name retained binaries are stored under.
+func binaryName() string {
+	if runtime.GOOS == "windows" {
+		return "core.exe"
+	}
+	return "core"
+}
+
+// modTime returns the modification time of path, which approximates when
+// the binary was installed, or the zero time if it cannot be read.
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime().UTC()
+}
+
+// moveFile renames src to dst, falling back to copy and delete when they
+// are on different filesystems.
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return err
+	}
+
+	// Windows cannot delete a running executable; the copy is what matters
+	in.Close()
+	_ = os.Remove(src)
+	return nil
+}
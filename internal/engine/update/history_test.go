@@ -0,0 +1,135 @@
+package update
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHistory_RetainAndPrune(t *testing.T) {
+	stateDir := t.TempDir()
+	binDir := t.TempDir()
+	history := NewHistory(stateDir, 2)
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, v := range []string{"0.1.0", "0.2.0", "0.3.0"} {
+		path := filepath.Join(binDir, "core-"+v)
+		os.WriteFile(path, []byte("binary "+v), 0755)
+
+		err := history.Retain(path, BackupEntry{
+			Version:    v,
+			Commit:     fmt.Sprintf("c%d", i),
+			RetainedAt: base.Add(time.Duration(i) * time.Hour),
+		})
+		if err != nil {
+			t.Fatalf("Retain(%s) failed: %v", v, err)
+		}
+	}
+
+	entries, err := history.List()
+	if err != nil {
+		t.Fatalf("List() failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 retained entries, got %d", len(entries))
+	}
+	if entries[0].Version != "0.3.0" || entries[1].Version != "0.2.0" {
+		t.Errorf("Unexpected order: %s, %s", entries[0].Version, entries[1].Version)
+	}
+
+	content, err := os.ReadFile(entries[0].Path)
+	if err != nil || string(content) != "binary 0.3.0" {
+		t.Errorf("Retained binary content mismatch: %q, %v", content, err)
+	}
+
+	if _, err := os.Stat(filepath.Join(stateDir, versionsDir, "0.1.0")); !os.IsNotExist(err) {
+		t.Error("Pruned version directory should be removed")
+	}
+
+	if _, err := history.Find("0.1.0"); !errors.Is(err, ErrNoBackup) {
+		t.Errorf("Expected ErrNoBackup for pruned version, got %v", err)
+	}
+}
+
+func TestHistory_Restore(t *testing.T) {
+	stateDir := t.TempDir()
+	binDir := t.TempDir()
+	history := NewHistory(stateDir, 3)
+
+	previous := filepath.Join(binDir, "previous")
+	os.WriteFile(previous, []byte("binary 0.1.0"), 0755)
+	if err := history.Retain(previous, BackupEntry{Version: "0.1.0"}); err != nil {
+		t.Fatalf("Retain() failed: %v", err)
+	}
+
+	target := filepath.Join(binDir, "core")
+	os.WriteFile(target, []byte("binary 0.2.0"), 0755)
+
+	restored, err := history.Restore("", target, BackupEntry{Version: "0.2.0"})
+	if err != nil {
+		t.Fatalf("Restore() failed: %v", err)
+	}
+	if restored.Version != "0.1.0" {
+		t.Errorf("Expected to restore 0.1.0, got %s", restored.Version)
+	}
+
+	content, _ := os.ReadFile(target)
+	if string(content) != "binary 0.1.0" {
+		t.Errorf("Target not restored, got %q", content)
+	}
+
+	// The replaced binary is retained so the rollback can be undone
+	entries, _ := history.List()
+	if len(entries) != 1 || entries[0].Version != "0.2.0" {
+		t.Fatalf("Expected only 0.2.0 to be retained, got %+v", entries)
+	}
+	if _, err := os.Stat(oldSavePath(target)); !os.IsNotExist(err) {
+		t.Error("Old binary should be moved into the state directory")
+	}
+}
+
+func TestUpdater_Apply_RetainsPrevious(t *testing.T) {
+	content := []byte("new binary")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(content)
+	}))
+	defer server.Close()
+
+	stateDir := t.TempDir()
+	target := filepath.Join(t.TempDir(), "core")
+	os.WriteFile(target, []byte("old binary"), 0755)
+
+	updater := NewUpdater(UpdaterConfig{
+		DownloadURL:    server.URL,
+		TargetPath:     target,
+		ChecksumPolicy: ChecksumSkip,
+		StateDir:       stateDir,
+		CurrentVersion: "0.1.0",
+		CurrentCommit:  "abc123",
+	})
+
+	if err := updater.Apply(); err != nil {
+		t.Fatalf("Apply() failed: %v", err)
+	}
+
+	entry, err := NewHistory(stateDir, 0).Find("0.1.0")
+	if err != nil {
+		t.Fatalf("Previous binary not retained: %v", err)
+	}
+	if entry.Commit != "abc123" || entry.InstalledAt.IsZero() {
+		t.Errorf("Unexpected metadata: %+v", entry)
+	}
+	if updater.BackupPath() != entry.Path {
+		t.Errorf("BackupPath() = %q, want %q", updater.BackupPath(), entry.Path)
+	}
+
+	old, _ := os.ReadFile(entry.Path)
+	if string(old) != "old binary" {
+		t.Errorf("Retained content mismatch: %q", old)
+	}
+}
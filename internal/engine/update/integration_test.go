@@ -4,6 +4,7 @@
 package update
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/json"
 	"fmt"
@@ -93,6 +94,7 @@ func TestIntegration_FullUpdateWorkflow(t *testing.T) {
 		DownloadURL: fmt.Sprintf("%s/download/binary", server.URL),
 		ChecksumURL: fmt.Sprintf("%s/download/checksums", server.URL),
 		TargetPath:  tmpDir + "/core",
+		AssetName:   updateInfo.AssetName,
 	})
 
 	// Track progress events
@@ -102,7 +104,7 @@ func TestIntegration_FullUpdateWorkflow(t *testing.T) {
 	})
 
 	// Download the update (we won't actually apply it to avoid modifying system files)
-	downloadedFile, err := updater.download()
+	downloadedFile, err := updater.download(context.Background())
 	if err != nil {
 		t.Fatalf("download() failed: %v", err)
 	}
@@ -118,7 +120,7 @@ func TestIntegration_FullUpdateWorkflow(t *testing.T) {
 	}
 
 	// Step 3: Verify checksum
-	err = updater.verifyChecksum(downloadedFile)
+	err = updater.verifyChecksum(context.Background(), downloadedFile)
 	if err != nil {
 		t.Errorf("Checksum verification failed: %v", err)
 	}
@@ -184,7 +186,8 @@ func TestIntegration_UpdateCheckAndComparison(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			available, compatible := checker.compareVersions(tt.current, tt.latest)
+			direction, compatible := checker.compareVersions(tt.current, tt.latest)
+			available := direction == DirectionUpgrade
 
 			if available != tt.expectAvail {
 				t.Errorf("compareVersions() available = %v, want %v", available, tt.expectAvail)
@@ -209,9 +212,9 @@ func TestIntegration_MultiPlatformAssetSelection(t *testing.T) {
 		CurrentVersion: "1.0.0",
 	})
 
-	release := &GitHubRelease{
+	release := &Release{
 		TagName: "v1.1.0",
-		Assets: []GitHubAsset{
+		Assets: []Asset{
 			{Name: "core-linux-amd64", DownloadURL: "https://example.com/linux-amd64"},
 			{Name: "core-linux-arm64", DownloadURL: "https://example.com/linux-arm64"},
 			{Name: "core-darwin-amd64", DownloadURL: "https://example.com/darwin-amd64"},
@@ -325,7 +328,7 @@ func TestIntegration_ErrorRecovery(t *testing.T) {
 				TargetPath:  tmpDir + "/core",
 			})
 
-			_, err := updater.download()
+			_, err := updater.download(context.Background())
 			if (err != nil) != tt.wantErr {
 				t.Errorf("download() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -363,7 +366,7 @@ func TestIntegration_ProgressTracking(t *testing.T) {
 		progressEvents = append(progressEvents, up)
 	})
 
-	_, err := updater.download()
+	_, err := updater.download(context.Background())
 	if err != nil {
 		t.Fatalf("download() failed: %v", err)
 	}
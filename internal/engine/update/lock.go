@@ -0,0 +1,153 @@
+package update
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const lockFile = "update.lock"
+
+// ErrUpdateLocked is returned when another process holds the update lock.
+// The error is a *LockedError describing the holder.
+var ErrUpdateLocked = errors.New("update already in progress")
+
+// LockInfo is the content of the update lock file.
+type LockInfo struct {
+	PID       int       `json:"pid"`
+	Host      string    `json:"host,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// LockedError reports the process holding the update lock.
+type LockedError struct {
+	LockInfo
+	Path string
+}
+
+func (e *LockedError) Error() string {
+	msg := ErrUpdateLocked.Error()
+	if e.PID > 0 {
+		msg += fmt.Sprintf(" by PID %d", e.PID)
+	}
+	if host, _ := os.Hostname(); e.Host != "" && e.Host != host {
+		msg += " on " + e.Host
+	}
+	return fmt.Sprintf("%s since %s (lock file %s)", msg, e.StartedAt.Local().Format(time.DateTime), e.Path)
+}
+
+func (e *LockedError) Unwrap() error { return ErrUpdateLocked }
+
+// errLockHeld is returned by tryLock when another open file holds the lock.
+var errLockHeld = errors.New("lock held")
+
+// Lock is a held update lock.
+type Lock struct {
+	file *os.File
+}
+
+// AcquireLock takes the update lock in stateDir, so only one process
+// downloads into or replaces the binary at a time. The lock is an OS
+// advisory lock on update.lock, which the OS releases when its holder exits,
+// so a crashed update never blocks the next one and taking it over is
+// atomic. This replaces detecting stale locks by PID liveness and age: a
+// held lock always belongs to a running process, and taking over an old
+// one would break a slow update that is still running. The file records
+// the holder for error messages. A lock held by another process fails with
+// a *LockedError.
+func AcquireLock(stateDir string) (*Lock, error) {
+	if err := os.MkdirAll(stateDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	path := filepath.Join(stateDir, lockFile)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create update lock: %w", err)
+	}
+
+	if err := tryLock(f, true); err != nil {
+		f.Close()
+		if errors.Is(err, errLockHeld) {
+			return nil, lockedError(path)
+		}
+		return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+
+	host, _ := os.Hostname()
+	data, err := json.Marshal(LockInfo{PID: os.Getpid(), Host: host, StartedAt: time.Now().UTC()})
+	if err == nil {
+		err = f.Truncate(0)
+	}
+	if err == nil {
+		_, err = f.WriteAt(data, 0)
+	}
+	if err != nil {
+		unlock(f)
+		f.Close()
+		return nil, fmt.Errorf("failed to write update lock: %w", err)
+	}
+
+	return &Lock{file: f}, nil
+}
+
+// CheckLock reports whether another process holds the update lock in
+// stateDir, returning a *LockedError if so. Nothing is written.
+func CheckLock(stateDir string) error {
+	path := filepath.Join(stateDir, lockFile)
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open update lock: %w", err)
+	}
+	defer f.Close()
+
+	// A shared lock conflicts only with a holder's exclusive one
+	err = tryLock(f, false)
+	if errors.Is(err, errLockHeld) {
+		return lockedError(path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check update lock: %w", err)
+	}
+	unlock(f)
+	return nil
+}
+
+// lockedError describes the holder of the lock at path from what it wrote
+// there. A holder that has not written yet is reported without details.
+func lockedError(path string) *LockedError {
+	locked := &LockedError{Path: path}
+	if data, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(data, &locked.LockInfo)
+	}
+	if locked.StartedAt.IsZero() {
+		locked.StartedAt = modTime(path)
+	}
+	return locked
+}
+
+// Release gives up the lock and clears the holder from the lock file. It is
+// safe to call more than once.
+func (l *Lock) Release() error {
+	if l == nil || l.file == nil {
+		return nil
+	}
+
+	// The file stays; removing it would let the next holder lock a file
+	// that a third process then recreates under the same name
+	terr := l.file.Truncate(0)
+	uerr := unlock(l.file)
+	cerr := l.file.Close()
+	l.file = nil
+	if err := errors.Join(terr, uerr, cerr); err != nil {
+		return fmt.Errorf("failed to release update lock: %w", err)
+	}
+	return nil
+}
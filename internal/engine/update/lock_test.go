@@ -0,0 +1,218 @@
+package update
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// writeLock writes a lock file as another process would.
+func writeLock(t *testing.T, dir string, info LockInfo) {
+	t.Helper()
+	data, _ := json.Marshal(info)
+	if err := os.WriteFile(filepath.Join(dir, lockFile), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// exitedPID returns the PID of a process that has already exited.
+func exitedPID(t *testing.T) int {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("failed to run helper process: %v", err)
+	}
+	return cmd.Process.Pid
+}
+
+func TestAcquireLock(t *testing.T) {
+	dir := t.TempDir()
+
+	lock, err := AcquireLock(dir)
+	if err != nil {
+		t.Fatalf("AcquireLock() failed: %v", err)
+	}
+
+	_, err = AcquireLock(dir)
+	var locked *LockedError
+	if !errors.As(err, &locked) || !errors.Is(err, ErrUpdateLocked) {
+		t.Fatalf("Expected a LockedError, got %v", err)
+	}
+	if locked.PID != os.Getpid() {
+		t.Errorf("Lock holder PID = %d, want %d", locked.PID, os.Getpid())
+	}
+	if want := fmt.Sprintf("update already in progress by PID %d", os.Getpid()); !strings.Contains(err.Error(), want) {
+		t.Errorf("Expected %q in error, got: %v", want, err)
+	}
+
+	if err := lock.Release(); err != nil {
+		t.Fatalf("Release() failed: %v", err)
+	}
+	if err := lock.Release(); err != nil {
+		t.Errorf("Second Release() failed: %v", err)
+	}
+
+	lock, err = AcquireLock(dir)
+	if err != nil {
+		t.Fatalf("AcquireLock() after release failed: %v", err)
+	}
+	lock.Release()
+}
+
+func TestAcquireLock_Stale(t *testing.T) {
+	host, _ := os.Hostname()
+
+	tests := []struct {
+		name    string
+		content func(t *testing.T) []byte
+	}{
+		{"exited process", func(t *testing.T) []byte {
+			data, _ := json.Marshal(LockInfo{PID: exitedPID(t), Host: host, StartedAt: time.Now()})
+			return data
+		}},
+		{"truncated", func(t *testing.T) []byte {
+			return []byte(`{"pid":`)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, lockFile), tt.content(t), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := CheckLock(dir); err != nil {
+				t.Errorf("CheckLock() = %v for a lock nobody holds", err)
+			}
+			lock, err := AcquireLock(dir)
+			if err != nil {
+				t.Fatalf("Expected the stale lock to be taken over, got %v", err)
+			}
+			lock.Release()
+		})
+	}
+}
+
+func TestAcquireLock_HeldElsewhere(t *testing.T) {
+	dir := t.TempDir()
+	writeLock(t, dir, LockInfo{PID: 4242, Host: "build-42", StartedAt: time.Now()})
+
+	// Another open file holding the lock stands in for another process
+	f, err := os.Open(filepath.Join(dir, lockFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := tryLock(f, true); err != nil {
+		t.Fatalf("tryLock() failed: %v", err)
+	}
+
+	var locked *LockedError
+	if err := CheckLock(dir); !errors.As(err, &locked) || locked.PID != 4242 {
+		t.Errorf("CheckLock() = %v, want a LockedError for PID 4242", err)
+	}
+	if _, err := AcquireLock(dir); !errors.Is(err, ErrUpdateLocked) {
+		t.Errorf("Expected ErrUpdateLocked, got %v", err)
+	}
+
+	// The OS drops the lock with its holder
+	unlock(f)
+	lock, err := AcquireLock(dir)
+	if err != nil {
+		t.Fatalf("AcquireLock() after the holder went away failed: %v", err)
+	}
+	lock.Release()
+}
+
+func TestAcquireLock_ConcurrentTakeover(t *testing.T) {
+	host, _ := os.Hostname()
+	dir := t.TempDir()
+	writeLock(t, dir, LockInfo{PID: exitedPID(t), Host: host, StartedAt: time.Now()})
+
+	const workers = 16
+	var (
+		wg    sync.WaitGroup
+		start = make(chan struct{})
+		locks = make(chan *Lock, workers)
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			if lock, err := AcquireLock(dir); err == nil {
+				locks <- lock
+			} else if !errors.Is(err, ErrUpdateLocked) {
+				t.Errorf("AcquireLock() failed: %v", err)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(locks)
+
+	// Nothing is released until every attempt is over, so only one may win
+	held := 0
+	for lock := range locks {
+		held++
+		lock.Release()
+	}
+	if held != 1 {
+		t.Errorf("%d goroutines hold the lock at once, want 1", held)
+	}
+}
+
+func TestUpdater_Apply_Locked(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte("new binary"))
+	}))
+	defer server.Close()
+
+	stateDir := t.TempDir()
+	lock, err := AcquireLock(stateDir)
+	if err != nil {
+		t.Fatalf("AcquireLock() failed: %v", err)
+	}
+	defer lock.Release()
+
+	target := filepath.Join(t.TempDir(), "core")
+	os.WriteFile(target, []byte("old binary"), 0755)
+
+	updater := NewUpdater(UpdaterConfig{
+		DownloadURL:    server.URL,
+		TargetPath:     target,
+		ChecksumPolicy: ChecksumSkip,
+		StateDir:       stateDir,
+	})
+	if err := updater.Apply(); !errors.Is(err, ErrUpdateLocked) {
+		t.Fatalf("Expected ErrUpdateLocked, got %v", err)
+	}
+
+	if requests != 0 {
+		t.Errorf("Expected no download while locked, got %d requests", requests)
+	}
+	if installed, _ := os.ReadFile(target); string(installed) != "old binary" {
+		t.Error("Target must not change while another update holds the lock")
+	}
+
+	// The lock is released once the other update finishes
+	lock.Release()
+	if err := updater.Apply(); err != nil {
+		t.Fatalf("Apply() after release failed: %v", err)
+	}
+	if err := CheckLock(stateDir); err != nil {
+		t.Errorf("Expected the lock to be released after Apply, got %v", err)
+	}
+}
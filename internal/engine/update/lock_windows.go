@@ -0,0 +1,38 @@
+//go:build windows
+
+package update
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// LockFileEx locks are mandatory for the locked bytes, so the lock covers
+// one byte far past the end of the file. The holder written at the start
+// stays readable for the "update already in progress by PID N" message.
+const lockOffsetHigh = 1 << 30
+
+// lockRegion returns the position of the locked byte.
+func lockRegion() *windows.Overlapped {
+	return &windows.Overlapped{OffsetHigh: lockOffsetHigh}
+}
+
+// tryLock takes an exclusive or shared LockFileEx lock on f without waiting.
+func tryLock(f *os.File, exclusive bool) error {
+	flags := uint32(windows.LOCKFILE_FAIL_IMMEDIATELY)
+	if exclusive {
+		flags |= windows.LOCKFILE_EXCLUSIVE_LOCK
+	}
+	err := windows.LockFileEx(windows.Handle(f.Fd()), flags, 0, 1, 0, lockRegion())
+	if errors.Is(err, windows.ERROR_LOCK_VIOLATION) {
+		return errLockHeld
+	}
+	return err
+}
+
+// unlock releases the lock on f.
+func unlock(f *os.File) error {
+	return windows.UnlockFileEx(windows.Handle(f.Fd()), 0, 1, 0, lockRegion())
+}
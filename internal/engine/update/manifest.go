@@ -0,0 +1,215 @@
+package update
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ManifestSchemaVersion is the newest release-manifest.json schema this
+// build understands. Manifests without a schema_version are read as
+// version 1.
+const ManifestSchemaVersion = 1
+
+// ErrInsecureManifest is returned for a manifest served over plain http://
+// without CheckerConfig.AllowInsecureManifest. The manifest supplies the
+// checksums the update is verified with, so anyone on the network path
+// could replace both the binary and its checksum.
+var ErrInsecureManifest = errors.New("release manifest is not served over https")
+
+// ReleaseManifest is the release-manifest.json document used to self-host
+// updates on a plain file server:
+//
+//	{
+//	  "schema_version": 1,
+//	  "releases": [{
+//	    "version": "1.2.0",
+//	    "channel": "stable",
+//	    "assets": [{
+//	      "name": "core-linux-amd64", "os": "linux", "arch": "amd64",
+//	      "url": "core/core-linux-amd64", "size": 9437184,
+//	      "sha256": "…", "signature": "core/core-linux-amd64.minisig"
+//	    }]
+//	  }]
+//	}
+//
+// Relative URLs are resolved against the manifest's own URL. Assets are
+// matched by name like release assets; when no name matches, the asset
+// whose os and arch fit the platform is used.
+type ReleaseManifest struct {
+	SchemaVersion int               `json:"schema_version"`
+	Releases      []ManifestRelease `json:"releases"`
+}
+
+// ManifestRelease describes one release in a manifest.
+type ManifestRelease struct {
+	Version         string          `json:"version"`
+	Channel         Channel         `json:"channel,omitempty"` // Derived from the version when empty
+	Date            string          `json:"date,omitempty"`
+	Notes           string          `json:"notes,omitempty"`
+	MinUpgradeFrom  string          `json:"min_upgrade_from,omitempty"`
+	BreakingChanges []string        `json:"breaking_changes,omitempty"`
+	Assets          []ManifestAsset `json:"assets"`
+}
+
+// ManifestAsset is a downloadable file of a manifest release.
+type ManifestAsset struct {
+	Name      string `json:"name"`
+	OS        string `json:"os,omitempty"`
+	Arch      string `json:"arch,omitempty"`
+	URL       string `json:"url"`
+	Size      int64  `json:"size,omitempty"`
+	SHA256    string `json:"sha256,omitempty"`
+	Signature string `json:"signature,omitempty"` // URL of a detached minisign signature
+}
+
+// ManifestSource reads releases from a release-manifest.json served over
+// https:// or read from a file:// URL. Plain http:// is accepted only with
+// CheckerConfig.AllowInsecureManifest.
+type ManifestSource struct {
+	url       string
+	allowHTTP bool
+	fetch     *fetcher
+}
+
+// newManifestSource creates a source for the manifest at SourceURL.
+func newManifestSource(config CheckerConfig, f *fetcher) *ManifestSource {
+	return &ManifestSource{
+		url:       strings.TrimSpace(config.SourceURL),
+		allowHTTP: config.AllowInsecureManifest,
+		fetch:     f,
+	}
+}
+
+// Name implements ReleaseSource.
+func (s *ManifestSource) Name() string {
+	return "manifest"
+}
+
+// LatestRelease implements ReleaseSource.
+func (s *ManifestSource) LatestRelease(ctx context.Context) (*Release, error) {
+	releases, err := s.ListReleases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return highestRelease(releases, ChannelStable)
+}
+
+// ReleaseByTag implements ReleaseSource.
+func (s *ManifestSource) ReleaseByTag(ctx context.Context, tag string) (*Release, error) {
+	releases, err := s.ListReleases(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range releases {
+		if NormalizeVersion(releases[i].TagName) == NormalizeVersion(tag) {
+			return &releases[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s is not in the manifest", ErrReleaseNotFound, tag)
+}
+
+// ListReleases implements ReleaseSource.
+func (s *ManifestSource) ListReleases(ctx context.Context) ([]Release, error) {
+	if s.url == "" {
+		return nil, fmt.Errorf("no release manifest URL configured")
+	}
+
+	base, err := url.Parse(s.url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid release manifest URL: %w", err)
+	}
+	switch base.Scheme {
+	case "https", "file":
+	case "http":
+		if !s.allowHTTP {
+			return nil, fmt.Errorf("%w: %s", ErrInsecureManifest, s.url)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported release manifest URL scheme %q", base.Scheme)
+	}
+
+	var manifest ReleaseManifest
+	if err := s.fetch.getJSON(ctx, s.Name(), s.url, nil, &manifest); err != nil {
+		return nil, err
+	}
+
+	if manifest.SchemaVersion > ManifestSchemaVersion {
+		return nil, fmt.Errorf("release manifest uses schema version %d, this build supports up to %d",
+			manifest.SchemaVersion, ManifestSchemaVersion)
+	}
+
+	releases := make([]Release, 0, len(manifest.Releases))
+	for _, r := range manifest.Releases {
+		release, err := r.release(base)
+		if err != nil {
+			return nil, err
+		}
+		releases = append(releases, release)
+	}
+
+	return releases, nil
+}
+
+func (s *ManifestSource) endpoint() string {
+	return s.url
+}
+
+// release converts a manifest entry into a Release, resolving asset URLs
+// against the manifest URL.
+func (r ManifestRelease) release(base *url.URL) (Release, error) {
+	release := Release{
+		TagName: "v" + NormalizeVersion(r.Version),
+		Body:    r.Notes,
+		Metadata: ReleaseMetadata{
+			MinUpgradeFrom:  r.MinUpgradeFrom,
+			BreakingChanges: r.BreakingChanges,
+		},
+	}
+
+	if r.Channel != "" {
+		channel, err := ParseChannel(string(r.Channel))
+		if err != nil {
+			return Release{}, fmt.Errorf("release %s: %w", r.Version, err)
+		}
+		release.Channel = channel
+	}
+
+	resolve := func(ref string) (string, error) {
+		if ref == "" {
+			return "", nil
+		}
+		u, err := url.Parse(ref)
+		if err != nil {
+			return "", err
+		}
+		return base.ResolveReference(u).String(), nil
+	}
+
+	for _, asset := range r.Assets {
+		downloadURL, err := resolve(asset.URL)
+		if err != nil {
+			return Release{}, fmt.Errorf("invalid URL for asset %s: %w", asset.Name, err)
+		}
+		signatureURL, err := resolve(asset.Signature)
+		if err != nil {
+			return Release{}, fmt.Errorf("invalid signature URL for asset %s: %w", asset.Name, err)
+		}
+
+		release.Assets = append(release.Assets, Asset{
+			Name:         asset.Name,
+			DownloadURL:  downloadURL,
+			Size:         asset.Size,
+			SHA256:       strings.ToLower(asset.SHA256),
+			SignatureURL: signatureURL,
+			OS:           asset.OS,
+			Arch:         asset.Arch,
+		})
+	}
+
+	return release, nil
+}
@@ -0,0 +1,210 @@
+package update
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// writeManifest stores a manifest in dir and returns its file:// URL.
+func writeManifest(t *testing.T, dir string, manifest ReleaseManifest) string {
+	t.Helper()
+
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "release-manifest.json")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
+
+func TestManifestSource_FileURL(t *testing.T) {
+	dir := t.TempDir()
+	binary := "core-" + runtime.GOOS + "-" + runtime.GOARCH
+	content := []byte("new binary")
+	sum := sha256.Sum256(content)
+
+	os.MkdirAll(filepath.Join(dir, "core"), 0755)
+	os.WriteFile(filepath.Join(dir, "core", binary), content, 0755)
+
+	manifestURL := writeManifest(t, dir, ReleaseManifest{
+		SchemaVersion: 1,
+		Releases: []ManifestRelease{
+			{
+				Version: "1.1.0",
+				Notes:   "Faster startup",
+				Assets: []ManifestAsset{{
+					Name:      binary,
+					URL:       "core/" + binary,
+					Size:      int64(len(content)),
+					SHA256:    hex.EncodeToString(sum[:]),
+					Signature: "core/" + binary + ".minisig",
+				}},
+			},
+			// Explicit channel wins over the plain version
+			{Version: "1.2.0", Channel: ChannelBeta},
+		},
+	})
+
+	checker := NewChecker(CheckerConfig{
+		CurrentVersion: "1.0.0",
+		SourceType:     SourceManifest,
+		SourceURL:      manifestURL,
+	})
+
+	info, err := checker.Check()
+	if err != nil {
+		t.Fatalf("Check() failed: %v", err)
+	}
+	if info.LatestVersion != "1.1.0" {
+		t.Errorf("Expected stable 1.1.0, got %s", info.LatestVersion)
+	}
+	if info.SHA256 != hex.EncodeToString(sum[:]) || info.Size != int64(len(content)) {
+		t.Errorf("Expected digest and size from manifest, got %q / %d", info.SHA256, info.Size)
+	}
+	if filepath.Base(info.SignatureURL) != binary+".minisig" {
+		t.Errorf("Expected signature URL from manifest, got %s", info.SignatureURL)
+	}
+
+	// The resolved file:// asset downloads and verifies like any other
+	updater := NewUpdater(UpdaterConfig{
+		DownloadURL: info.DownloadURL,
+		SHA256:      info.SHA256,
+		StateDir:    t.TempDir(),
+	})
+	path, err := updater.download(context.Background())
+	if err != nil {
+		t.Fatalf("download() of file URL failed: %v", err)
+	}
+	defer os.Remove(path)
+
+	if err := updater.verifyChecksum(context.Background(), path); err != nil {
+		t.Errorf("verifyChecksum() with manifest digest failed: %v", err)
+	}
+
+	updater.config.SHA256 = hex.EncodeToString(make([]byte, 32))
+	if err := updater.verifyChecksum(context.Background(), path); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Expected ErrChecksumMismatch, got %v", err)
+	}
+}
+
+func TestManifestSource_DeclaredPlatform(t *testing.T) {
+	other := "windows"
+	if runtime.GOOS == "windows" {
+		other = "linux"
+	}
+
+	manifestURL := writeManifest(t, t.TempDir(), ReleaseManifest{
+		SchemaVersion: 1,
+		Releases: []ManifestRelease{{
+			Version: "1.1.0",
+			Assets: []ManifestAsset{
+				{Name: "core_1.1.0_other", OS: other, Arch: runtime.GOARCH, URL: "other"},
+				{Name: "core_1.1.0_build", OS: runtime.GOOS, Arch: runtime.GOARCH, URL: "build"},
+			},
+		}},
+	})
+
+	checker := NewChecker(CheckerConfig{
+		CurrentVersion: "1.0.0",
+		SourceType:     SourceManifest,
+		SourceURL:      manifestURL,
+		Platform:       Platform{OS: runtime.GOOS, Arch: runtime.GOARCH},
+	})
+
+	info, err := checker.Check()
+	if err != nil {
+		t.Fatalf("Check() failed: %v", err)
+	}
+	if info.AssetName != "core_1.1.0_build" {
+		t.Errorf("Expected the asset declared for %s/%s, got %q", runtime.GOOS, runtime.GOARCH, info.AssetName)
+	}
+}
+
+func TestManifestSource_RejectsPlainHTTP(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		json.NewEncoder(w).Encode(ReleaseManifest{
+			SchemaVersion: 1,
+			Releases:      []ManifestRelease{{Version: "1.1.0"}},
+		})
+	}))
+	defer server.Close()
+
+	config := CheckerConfig{
+		CurrentVersion: "1.0.0",
+		SourceType:     SourceManifest,
+		SourceURL:      server.URL + "/release-manifest.json",
+	}
+
+	if _, err := NewChecker(config).Check(); !errors.Is(err, ErrInsecureManifest) {
+		t.Errorf("Expected ErrInsecureManifest, got %v", err)
+	}
+	if requests != 0 {
+		t.Errorf("Insecure manifest was requested %d times", requests)
+	}
+
+	config.AllowInsecureManifest = true
+	info, err := NewChecker(config).Check()
+	if err != nil {
+		t.Fatalf("Check() with AllowInsecureManifest failed: %v", err)
+	}
+	if info.LatestVersion != "1.1.0" {
+		t.Errorf("Expected 1.1.0, got %s", info.LatestVersion)
+	}
+}
+
+func TestManifestSource_UnsupportedSchema(t *testing.T) {
+	manifestURL := writeManifest(t, t.TempDir(), ReleaseManifest{SchemaVersion: ManifestSchemaVersion + 1})
+
+	checker := NewChecker(CheckerConfig{
+		CurrentVersion: "1.0.0",
+		SourceType:     SourceManifest,
+		SourceURL:      manifestURL,
+	})
+
+	if _, err := checker.Check(); err == nil {
+		t.Error("Expected an error for a newer manifest schema")
+	}
+}
+
+func TestManifestSource_InlineMetadata(t *testing.T) {
+	manifestURL := writeManifest(t, t.TempDir(), ReleaseManifest{
+		Releases: []ManifestRelease{{
+			Version:         "2.0.0",
+			MinUpgradeFrom:  "1.5.0",
+			BreakingChanges: []string{"Removed legacy flags"},
+		}},
+	})
+
+	checker := NewChecker(CheckerConfig{
+		CurrentVersion: "1.6.0",
+		SourceType:     SourceManifest,
+		SourceURL:      manifestURL,
+	})
+
+	info, err := checker.Check()
+	if err != nil {
+		t.Fatalf("Check() failed: %v", err)
+	}
+	if !info.Compatible {
+		t.Error("Expected min_upgrade_from from the manifest to allow 1.6.0 -> 2.0.0")
+	}
+	if len(info.BreakingChanges) != 1 {
+		t.Errorf("Expected breaking changes from the manifest, got %v", info.BreakingChanges)
+	}
+}
@@ -0,0 +1,145 @@
+package update
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	mirrorHealthFile = "mirrors.json"
+	// mirrorPenalty is how long a failed mirror is tried after healthy ones.
+	mirrorPenalty = time.Hour
+)
+
+// mirrorURL returns where a mirror serves a release asset. Mirrors copy the
+// release layout as <base>/v<version>/<asset>.
+func mirrorURL(base, version, assetName string) string {
+	return fmt.Sprintf("%s/v%s/%s", strings.TrimRight(base, "/"), NormalizeVersion(version), assetName)
+}
+
+// mirrorName identifies a mirror in progress events and health records.
+func mirrorName(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		if err == nil && u.Scheme == "file" {
+			return "local"
+		}
+		return rawURL
+	}
+	return u.Host
+}
+
+// mirrorHealth is the recent track record of one mirror.
+type mirrorHealth struct {
+	LastSuccess time.Time `json:"last_success,omitempty"`
+	LastFailure time.Time `json:"last_failure,omitempty"`
+	Failures    int       `json:"failures,omitempty"` // Consecutive failures
+}
+
+// unhealthy reports whether the mirror failed recently and has not
+// recovered since.
+func (h mirrorHealth) unhealthy(now time.Time) bool {
+	return h.Failures > 0 && now.Sub(h.LastFailure) < mirrorPenalty
+}
+
+// mirrorTracker orders download candidates by mirror health. Records are
+// kept in the state directory when one is configured, otherwise for the
+// lifetime of the updater.
+type mirrorTracker struct {
+	path string
+
+	mu      sync.Mutex
+	entries map[string]mirrorHealth
+}
+
+func newMirrorTracker(stateDir string) *mirrorTracker {
+	t := &mirrorTracker{entries: make(map[string]mirrorHealth)}
+	if stateDir == "" {
+		return t
+	}
+
+	t.path = filepath.Join(stateDir, mirrorHealthFile)
+	if data, err := os.ReadFile(t.path); err == nil {
+		if err := json.Unmarshal(data, &t.entries); err != nil {
+			t.entries = make(map[string]mirrorHealth)
+		}
+	}
+	return t
+}
+
+// order returns urls with recently failing mirrors moved to the end, least
+// recently failed first. Healthy mirrors keep their configured order.
+func (t *mirrorTracker) order(urls []string) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	ordered := append([]string(nil), urls...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		hi, hj := t.entries[mirrorName(ordered[i])], t.entries[mirrorName(ordered[j])]
+		ui, uj := hi.unhealthy(now), hj.unhealthy(now)
+		if ui != uj {
+			return !ui
+		}
+		if ui {
+			return hi.LastFailure.Before(hj.LastFailure)
+		}
+		return false
+	})
+	return ordered
+}
+
+// record stores the outcome of a request to a mirror. Persisting is best
+// effort; health only affects ordering.
+func (t *mirrorTracker) record(rawURL string, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	name := mirrorName(rawURL)
+	health := t.entries[name]
+	if err == nil {
+		health.LastSuccess = time.Now()
+		health.Failures = 0
+	} else {
+		health.LastFailure = time.Now()
+		health.Failures++
+	}
+	t.entries[name] = health
+
+	if t.path == "" {
+		return
+	}
+	if data, err := json.Marshal(t.entries); err == nil {
+		_ = os.WriteFile(t.path, data, 0644)
+	}
+}
+
+// downloadCandidates returns the URLs to download the asset from, most
+// promising first. DownloadURL is always among them.
+func (u *Updater) downloadCandidates() []string {
+	seen := make(map[string]bool)
+	var urls []string
+	for _, candidate := range append(append([]string(nil), u.config.DownloadURLs...), u.config.DownloadURL) {
+		if candidate != "" && !seen[candidate] {
+			seen[candidate] = true
+			urls = append(urls, candidate)
+		}
+	}
+	return u.mirrors.order(urls)
+}
+
+// splitURL splits a URL at its last slash.
+func splitURL(rawURL string) (dir, name string) {
+	i := strings.LastIndex(rawURL, "/")
+	if i < 0 {
+		return "", rawURL
+	}
+	return rawURL[:i], rawURL[i+1:]
+}
@@ -0,0 +1,222 @@
+package update
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestUpdater_Download_FallsBackToNextMirror(t *testing.T) {
+	content := []byte("binary from mirror")
+	sum := sha256.Sum256(content)
+
+	var brokenHits int32
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The source still publishes the checksum file, only the asset fails
+		if r.URL.Path == "/releases/download/v1.2.0/checksums.txt" {
+			fmt.Fprintf(w, "%s  core-linux-amd64\n", hex.EncodeToString(sum[:]))
+			return
+		}
+		atomic.AddInt32(&brokenHits, 1)
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer broken.Close()
+
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1.2.0/core-linux-amd64":
+			w.Write(content)
+		case "/v1.2.0/checksums.txt":
+			fmt.Fprintf(w, "%s  core-linux-amd64\n", hex.EncodeToString(sum[:]))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer mirror.Close()
+
+	stateDir := t.TempDir()
+	newUpdater := func() *Updater {
+		return NewUpdater(UpdaterConfig{
+			DownloadURL:    broken.URL + "/releases/download/v1.2.0/core-linux-amd64",
+			ChecksumURL:    broken.URL + "/releases/download/v1.2.0/checksums.txt",
+			DownloadURLs:   []string{broken.URL + "/releases/download/v1.2.0/core-linux-amd64", mirror.URL + "/v1.2.0/core-linux-amd64"},
+			StateDir:       stateDir,
+			RetryBaseDelay: time.Millisecond,
+		})
+	}
+
+	updater := newUpdater()
+	var fallback, servedBy string
+	updater.SetProgressCallback(func(p UpdateProgress) {
+		switch p.Stage {
+		case "fallback":
+			fallback = p.Mirror
+		case "downloading":
+			if p.Mirror != "" {
+				servedBy = p.Mirror
+			}
+		}
+	})
+
+	path, err := updater.download(context.Background())
+	if err != nil {
+		t.Fatalf("download() failed: %v", err)
+	}
+	defer os.Remove(path)
+
+	mirrorHost := mirrorName(mirror.URL)
+	if fallback != mirrorHost || servedBy != mirrorHost {
+		t.Errorf("Expected fallback to and download from %s, got %q and %q", mirrorHost, fallback, servedBy)
+	}
+
+	// The checksum comes from the source even though the mirror served the asset
+	if err := updater.verifyChecksum(context.Background(), path); err != nil {
+		t.Errorf("verifyChecksum() of mirrored asset failed: %v", err)
+	}
+
+	// The failure is remembered: the next updater starts with the mirror
+	hits := atomic.LoadInt32(&brokenHits)
+	second := newUpdater()
+	if got := second.downloadCandidates()[0]; got != mirror.URL+"/v1.2.0/core-linux-amd64" {
+		t.Errorf("Expected healthy mirror first, got %s", got)
+	}
+	path, err = second.download(context.Background())
+	if err != nil {
+		t.Fatalf("second download() failed: %v", err)
+	}
+	os.Remove(path)
+	if atomic.LoadInt32(&brokenHits) != hits {
+		t.Error("Expected the failing source to be skipped once a mirror succeeded")
+	}
+}
+
+func TestUpdater_Apply_RejectsTamperedMirror(t *testing.T) {
+	content := []byte("genuine binary")
+	tampered := []byte("tampered binary")
+
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1.2.0/core-linux-amd64":
+			w.Write(content)
+		case "/v1.2.0/checksums.txt":
+			fmt.Fprintf(w, "%x  core-linux-amd64\n", sha256.Sum256(content))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer source.Close()
+
+	// The mirror serves a modified binary with a checksum file to match
+	var mirrorChecksumHits int32
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1.2.0/core-linux-amd64":
+			w.Write(tampered)
+		case "/v1.2.0/checksums.txt":
+			atomic.AddInt32(&mirrorChecksumHits, 1)
+			fmt.Fprintf(w, "%x  core-linux-amd64\n", sha256.Sum256(tampered))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer mirror.Close()
+
+	target := t.TempDir() + "/core"
+	os.WriteFile(target, []byte("old binary"), 0755)
+
+	updater := NewUpdater(UpdaterConfig{
+		DownloadURL:  source.URL + "/v1.2.0/core-linux-amd64",
+		DownloadURLs: []string{mirror.URL + "/v1.2.0/core-linux-amd64"},
+		ChecksumURL:  source.URL + "/v1.2.0/checksums.txt",
+		TargetPath:   target,
+	})
+
+	if err := updater.Apply(); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("Apply() = %v, want ErrChecksumMismatch", err)
+	}
+	if atomic.LoadInt32(&mirrorChecksumHits) != 0 {
+		t.Error("Expected the checksum file to come from the source only")
+	}
+	if data, _ := os.ReadFile(target); string(data) != "old binary" {
+		t.Errorf("Target was replaced with %q", data)
+	}
+}
+
+func TestUpdater_Download_AllMirrorsFail(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	updater := NewUpdater(UpdaterConfig{
+		DownloadURL:  server.URL + "/a/core",
+		DownloadURLs: []string{server.URL + "/b/core"},
+	})
+
+	if _, err := updater.download(context.Background()); err == nil {
+		t.Error("Expected download to fail when every mirror fails")
+	}
+}
+
+func TestChecker_DownloadURLs(t *testing.T) {
+	binary := "core-" + runtime.GOOS + "-" + runtime.GOARCH
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/version/latest":
+			json.NewEncoder(w).Encode(coreVersionResponse{
+				Status: "ok",
+				Data:   coreVersionData{Version: "1.2.0", MirrorURL: "https://cdn.core.example"},
+			})
+		case "/gh/repos/o/r/releases/latest", "/gh/repos/o/r/releases/tags/v1.2.0":
+			json.NewEncoder(w).Encode(GitHubRelease{
+				TagName: "v1.2.0",
+				Assets: []GitHubAsset{{
+					Name:        binary,
+					DownloadURL: "https://github.com/o/r/releases/download/v1.2.0/" + binary,
+				}},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	checker := NewChecker(CheckerConfig{
+		APIBaseURL:       server.URL,
+		GitHubAPIBaseURL: server.URL + "/gh",
+		GitHubOwner:      "o",
+		GitHubRepo:       "r",
+		CurrentVersion:   "1.0.0",
+		Mirrors:          []string{"https://mirror.internal/core/"},
+	})
+
+	info, err := checker.Check()
+	if err != nil {
+		t.Fatalf("Check() failed: %v", err)
+	}
+
+	want := []string{
+		"https://cdn.core.example/v1.2.0/" + binary,
+		"https://mirror.internal/core/v1.2.0/" + binary,
+		"https://github.com/o/r/releases/download/v1.2.0/" + binary,
+	}
+	if len(info.DownloadURLs) != len(want) {
+		t.Fatalf("Expected %d download URLs, got %v", len(want), info.DownloadURLs)
+	}
+	for i := range want {
+		if info.DownloadURLs[i] != want[i] {
+			t.Errorf("DownloadURLs[%d] = %s, want %s", i, info.DownloadURLs[i], want[i])
+		}
+	}
+}
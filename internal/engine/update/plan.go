@@ -0,0 +1,141 @@
+package update
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// UpdatePlan describes what ApplyContext would do with the same
+// configuration. Checksum and Signature use the statuses of preflight
+// checks; a failed one means the update would be refused.
+type UpdatePlan struct {
+	AssetName string `json:"asset_name"`
+	// Mirrors are the download URLs in the order they would be tried.
+	Mirrors    []string `json:"mirrors"`
+	TargetPath string   `json:"target_path"`
+	// BackupPath is where the replaced binary would be retained, or "" when
+	// no backup would be kept.
+	BackupPath string `json:"backup_path,omitempty"`
+	// SelfTest reports whether the installed binary would be test-run.
+	SelfTest  bool           `json:"self_test"`
+	Checksum  PreflightCheck `json:"checksum"`
+	Signature PreflightCheck `json:"signature"`
+
+	errs []error
+}
+
+// Err returns why the planned update would fail, or nil.
+func (p *UpdatePlan) Err() error {
+	return errors.Join(p.errs...)
+}
+
+// Plan resolves what ApplyContext would do without downloading the binary
+// or writing anything: which mirrors would be tried, whether a checksum
+// and signature are published for the asset, and where the replaced
+// binary would be kept. The checksum file is fetched to find the asset's
+// entry. An error is returned only for an incomplete configuration.
+func (u *Updater) Plan(ctx context.Context) (*UpdatePlan, error) {
+	if u.config.DownloadURL == "" {
+		return nil, fmt.Errorf("download URL not specified")
+	}
+
+	if u.config.TargetPath == "" {
+		return nil, fmt.Errorf("target path not specified")
+	}
+
+	plan := &UpdatePlan{
+		AssetName:  u.assetName(),
+		Mirrors:    u.downloadCandidates(),
+		TargetPath: u.config.TargetPath,
+		SelfTest:   u.config.ExpectedVersion != "",
+	}
+
+	if u.config.StateDir != "" {
+		version := u.config.CurrentVersion
+		if version == "" {
+			version = "unknown"
+		}
+		plan.BackupPath = NewHistory(u.config.StateDir, u.config.KeepBackups).binaryPath(version)
+	}
+
+	var err error
+	plan.Checksum, err = u.planChecksum(ctx)
+	if err != nil {
+		plan.errs = append(plan.errs, err)
+	}
+	plan.Signature, err = u.planSignature()
+	if err != nil {
+		plan.errs = append(plan.errs, err)
+	}
+
+	return plan, nil
+}
+
+// planChecksum looks up the asset's published checksum and applies the
+// checksum policy to the outcome, as applyChecksumPolicy would.
+func (u *Updater) planChecksum(ctx context.Context) (PreflightCheck, error) {
+	check := PreflightCheck{Name: "checksum", Status: CheckOK}
+
+	policy := u.config.ChecksumPolicy
+	if policy == "" {
+		policy = ChecksumRequire
+	}
+
+	if policy == ChecksumSkip {
+		check.Status = CheckSkipped
+		check.Detail = "checksum verification is disabled"
+		return check, nil
+	}
+
+	expected, err := u.expectedChecksum(ctx)
+	switch {
+	case err == nil:
+		check.Detail = "SHA256 " + expected
+		return check, nil
+	case ctx.Err() != nil:
+		return check, ctx.Err()
+	case policy == ChecksumWarn:
+		check.Status = CheckWarn
+		check.Detail = "checksum would not be verified: " + err.Error()
+		return check, nil
+	}
+
+	check.Status = CheckFail
+	check.Detail = err.Error()
+	return check, err
+}
+
+// planSignature reports which detached signature verifySignature would
+// check. Signatures are not downloaded.
+func (u *Updater) planSignature() (PreflightCheck, error) {
+	check := PreflightCheck{Name: "signature", Status: CheckOK}
+
+	if strings.TrimSpace(u.config.PublicKey) == "" {
+		check.Status = CheckSkipped
+		check.Detail = "no update public key is configured"
+		return check, nil
+	}
+
+	if _, err := parsePublicKey(u.config.PublicKey); err != nil {
+		check.Status = CheckFail
+		check.Detail = err.Error()
+		return check, err
+	}
+
+	switch {
+	case u.config.SignatureURL != "":
+		_, name := splitURL(u.config.SignatureURL)
+		check.Detail = "asset is signed (" + name + ")"
+	case u.config.ChecksumSignatureURL != "" && u.config.ChecksumURL != "":
+		_, name := splitURL(u.config.ChecksumSignatureURL)
+		check.Detail = "checksum file is signed (" + name + ")"
+	default:
+		check.Status = CheckFail
+		check.Detail = ErrSignatureMissing.Error()
+		return check, ErrSignatureMissing
+	}
+
+	return check, nil
+}
@@ -0,0 +1,126 @@
+package update
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUpdater_Plan(t *testing.T) {
+	publicKey, _ := newTestKey(t)
+	sum := sha256.Sum256([]byte("new binary"))
+
+	var assetHits int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/checksums.txt":
+			fmt.Fprintf(w, "%x  core-linux-amd64\n", sum)
+		default:
+			assetHits++
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	stateDir := t.TempDir()
+	target := filepath.Join(t.TempDir(), "core")
+
+	updater := NewUpdater(UpdaterConfig{
+		DownloadURL:          server.URL + "/core-linux-amd64",
+		DownloadURLs:         []string{"https://mirror.example.com/v1.1.0/core-linux-amd64"},
+		ChecksumURL:          server.URL + "/checksums.txt",
+		ChecksumSignatureURL: server.URL + "/checksums.txt.minisig",
+		PublicKey:            publicKey,
+		TargetPath:           target,
+		StateDir:             stateDir,
+		CurrentVersion:       "1.0.0",
+		ExpectedVersion:      "1.1.0",
+	})
+
+	plan, err := updater.Plan(context.Background())
+	if err != nil {
+		t.Fatalf("Plan() failed: %v", err)
+	}
+	if err := plan.Err(); err != nil {
+		t.Errorf("Plan().Err() = %v, want nil", err)
+	}
+
+	if plan.AssetName != "core-linux-amd64" || plan.TargetPath != target || !plan.SelfTest {
+		t.Errorf("Unexpected plan: %+v", plan)
+	}
+	if len(plan.Mirrors) != 2 || plan.Mirrors[1] != server.URL+"/core-linux-amd64" {
+		t.Errorf("Mirrors = %v, want the mirror before the source", plan.Mirrors)
+	}
+	if want := filepath.Join(stateDir, "versions", "1.0.0", binaryName()); plan.BackupPath != want {
+		t.Errorf("BackupPath = %q, want %q", plan.BackupPath, want)
+	}
+	if plan.Checksum.Status != CheckOK || plan.Checksum.Detail != fmt.Sprintf("SHA256 %x", sum) {
+		t.Errorf("Checksum = %+v", plan.Checksum)
+	}
+	if plan.Signature.Status != CheckOK {
+		t.Errorf("Signature = %+v", plan.Signature)
+	}
+
+	// Nothing is downloaded or written
+	if assetHits != 0 {
+		t.Errorf("Plan() requested the asset %d times", assetHits)
+	}
+	if entries, _ := os.ReadDir(stateDir); len(entries) != 0 {
+		t.Errorf("Plan() wrote to the state directory: %v", entries)
+	}
+}
+
+func TestUpdater_Plan_Unverifiable(t *testing.T) {
+	publicKey, _ := newTestKey(t)
+
+	tests := []struct {
+		name           string
+		policy         ChecksumPolicy
+		wantChecksum   CheckStatus
+		wantErrorsFrom []error
+	}{
+		{"require", ChecksumRequire, CheckFail, []error{ErrChecksumUnavailable, ErrSignatureMissing}},
+		{"warn", ChecksumWarn, CheckWarn, []error{ErrSignatureMissing}},
+		{"skip", ChecksumSkip, CheckSkipped, []error{ErrSignatureMissing}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updater := NewUpdater(UpdaterConfig{
+				DownloadURL:    "https://example.com/core-linux-amd64",
+				TargetPath:     "/usr/local/bin/core",
+				ChecksumPolicy: tt.policy,
+				PublicKey:      publicKey,
+			})
+
+			plan, err := updater.Plan(context.Background())
+			if err != nil {
+				t.Fatalf("Plan() failed: %v", err)
+			}
+
+			if plan.Checksum.Status != tt.wantChecksum {
+				t.Errorf("Checksum status = %q, want %q", plan.Checksum.Status, tt.wantChecksum)
+			}
+			if plan.Signature.Status != CheckFail {
+				t.Errorf("Signature status = %q, want %q", plan.Signature.Status, CheckFail)
+			}
+			if plan.BackupPath != "" {
+				t.Errorf("BackupPath = %q without a state directory", plan.BackupPath)
+			}
+			for _, want := range tt.wantErrorsFrom {
+				if !errors.Is(plan.Err(), want) {
+					t.Errorf("Plan().Err() = %v, want %v", plan.Err(), want)
+				}
+			}
+			if tt.policy != ChecksumRequire && errors.Is(plan.Err(), ErrChecksumUnavailable) {
+				t.Errorf("Plan().Err() = %v, checksum should not fail with policy %q", plan.Err(), tt.policy)
+			}
+		})
+	}
+}
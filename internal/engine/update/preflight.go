@@ -0,0 +1,359 @@
+package update
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+)
+
+// ErrPreflightFailed is returned when the environment would make an update
+// fail, or the binary belongs to a package manager.
+var ErrPreflightFailed = errors.New("update preflight failed")
+
+// CheckStatus is the outcome of a preflight check.
+type CheckStatus string
+
+const (
+	// CheckOK means nothing stands in the way.
+	CheckOK CheckStatus = "ok"
+	// CheckWarn flags something worth knowing that does not stop an update.
+	CheckWarn CheckStatus = "warning"
+	// CheckFail means the update would fail or should not be attempted.
+	CheckFail CheckStatus = "fail"
+	// CheckSkipped means the check could not be run on this system.
+	CheckSkipped CheckStatus = "skipped"
+)
+
+// PreflightCheck is the result of one preflight check. Remedy says how to
+// fix a warning or failure.
+type PreflightCheck struct {
+	Name   string      `json:"name"`
+	Status CheckStatus `json:"status"`
+	Detail string      `json:"detail"`
+	Remedy string      `json:"remedy,omitempty"`
+}
+
+// PreflightReport is the outcome of Preflight.
+type PreflightReport struct {
+	TargetPath string `json:"target_path"`
+	// ResolvedPath is TargetPath with symlinks resolved; it is the file an
+	// update replaces.
+	ResolvedPath   string           `json:"resolved_path"`
+	PackageManager string           `json:"package_manager,omitempty"`
+	Checks         []PreflightCheck `json:"checks"`
+}
+
+// OK reports whether no check failed.
+func (r PreflightReport) OK() bool {
+	return r.Err() == nil
+}
+
+// Err returns an ErrPreflightFailed error naming the failed checks, or nil.
+func (r PreflightReport) Err() error {
+	var failed []string
+	for _, check := range r.Checks {
+		if check.Status == CheckFail {
+			failed = append(failed, check.Detail)
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrPreflightFailed, strings.Join(failed, "; "))
+}
+
+// PreflightConfig describes the update to check for.
+type PreflightConfig struct {
+	TargetPath string // Binary to replace (usually os.Executable())
+	// AssetSize is the size of the release asset. When unknown, the size of
+	// the current binary is used as an estimate.
+	AssetSize int64
+	// StateDir holds partial downloads and retained binaries (os.TempDir()
+	// when empty).
+	StateDir string
+}
+
+// packageManager describes a package manager that may own the binary.
+type packageManager struct {
+	name   string
+	remedy string
+}
+
+// dpkgInfoDir lists the files installed by each Debian package.
+var dpkgInfoDir = "/var/lib/dpkg/info"
+
+// Preflight checks that the binary at TargetPath can be replaced: that it is
+// not managed by a package manager, that its directory is writable and not
+// on a read-only mount, and that there is room for the download, the new
+// binary and the retained backup. Permissions are checked without creating
+// files, so nothing is written.
+func Preflight(config PreflightConfig) PreflightReport {
+	report := PreflightReport{TargetPath: config.TargetPath, ResolvedPath: config.TargetPath}
+
+	resolved, check := checkSymlink(config.TargetPath)
+	report.ResolvedPath = resolved
+	report.Checks = append(report.Checks, check)
+	if check.Status == CheckFail {
+		return report
+	}
+
+	pm, check := checkPackageManager(resolved)
+	if pm != nil {
+		report.PackageManager = pm.name
+	}
+	report.Checks = append(report.Checks, check)
+
+	report.Checks = append(report.Checks, checkDirectoryWritable(resolved)...)
+	report.Checks = append(report.Checks, checkBinaryWritable(resolved))
+	report.Checks = append(report.Checks, checkDiskSpace(resolved, config))
+
+	return report
+}
+
+// checkSymlink resolves the binary. Updating a symlink in place would
+// replace the link with a regular file, so the target is updated instead.
+func checkSymlink(path string) (string, PreflightCheck) {
+	check := PreflightCheck{Name: "symlink", Status: CheckOK, Detail: "binary is a regular file"}
+
+	info, err := os.Lstat(path)
+	if err != nil {
+		check.Status = CheckFail
+		check.Detail = fmt.Sprintf("cannot read %s: %v", path, err)
+		check.Remedy = "Reinstall CORE CLI; the running binary can no longer be found."
+		return path, check
+	}
+	if info.Mode()&fs.ModeSymlink == 0 {
+		if !info.Mode().IsRegular() {
+			check.Status = CheckFail
+			check.Detail = fmt.Sprintf("%s is not a regular file", path)
+			check.Remedy = "Reinstall CORE CLI as a regular file."
+		}
+		return path, check
+	}
+
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		check.Status = CheckFail
+		check.Detail = fmt.Sprintf("%s is a broken symlink: %v", path, err)
+		check.Remedy = "Point the symlink at an installed CORE CLI binary, or reinstall."
+		return path, check
+	}
+
+	check.Status = CheckWarn
+	check.Detail = fmt.Sprintf("%s is a symlink to %s; the update replaces %s", path, resolved, resolved)
+	return resolved, check
+}
+
+// checkPackageManager refuses binaries installed by a package manager,
+// which would overwrite or be confused by a self-update.
+func checkPackageManager(path string) (*packageManager, PreflightCheck) {
+	check := PreflightCheck{Name: "package-manager", Status: CheckOK, Detail: "not managed by a package manager"}
+
+	pm := detectPackageManager(path)
+	if pm != nil {
+		check.Status = CheckFail
+		check.Detail = fmt.Sprintf("%s is managed by %s", path, pm.name)
+		check.Remedy = pm.remedy
+		return pm, check
+	}
+
+	if runtime.GOOS != "windows" && isSystemDir(filepath.Dir(path)) {
+		check.Status = CheckWarn
+		check.Detail = fmt.Sprintf("%s is in a system directory usually managed by the OS package manager", path)
+		check.Remedy = "If CORE CLI was installed with a package manager, update it there instead."
+	}
+	return nil, check
+}
+
+// detectPackageManager identifies the package manager owning path, if any.
+func detectPackageManager(path string) *packageManager {
+	// Windows paths are matched with forward slashes too
+	slashed := strings.ReplaceAll(path, `\`, "/")
+
+	switch {
+	case strings.Contains(slashed, "/Cellar/") || strings.HasPrefix(slashed, "/home/linuxbrew/.linuxbrew/"):
+		return &packageManager{"Homebrew", "Run 'brew upgrade core' instead."}
+	case strings.HasPrefix(slashed, "/nix/store/"):
+		return &packageManager{"Nix", "Update the Nix profile or flake providing CORE CLI, e.g. 'nix profile upgrade core'."}
+	case strings.Contains(strings.ToLower(slashed), "/scoop/apps/"):
+		return &packageManager{"Scoop", "Run 'scoop update core' instead."}
+	case strings.HasPrefix(slashed, "/snap/"):
+		return &packageManager{"Snap", "Run 'sudo snap refresh core' instead."}
+	}
+
+	// apt never installs into /usr/local or home directories
+	if !isSystemDir(filepath.Dir(path)) && !strings.HasPrefix(slashed, "/opt/") {
+		return nil
+	}
+	if pkg := dpkgOwner(path); pkg != "" {
+		return &packageManager{"apt (package " + pkg + ")", fmt.Sprintf("Run 'sudo apt update && sudo apt install --only-upgrade %s' instead.", pkg)}
+	}
+	return nil
+}
+
+// dpkgOwner returns the Debian package that installed path, if any, by
+// searching the file lists in dpkgInfoDir.
+func dpkgOwner(path string) string {
+	lists, _ := filepath.Glob(filepath.Join(dpkgInfoDir, "*.list"))
+	for _, list := range lists {
+		f, err := os.Open(list)
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(f)
+		found := false
+		for scanner.Scan() {
+			if scanner.Text() == path {
+				found = true
+				break
+			}
+		}
+		f.Close()
+
+		if found {
+			// Multi-arch packages are listed as name:arch.list
+			name := strings.TrimSuffix(filepath.Base(list), ".list")
+			name, _, _ = strings.Cut(name, ":")
+			return name
+		}
+	}
+	return ""
+}
+
+// isSystemDir reports whether dir is a binary directory owned by the OS.
+func isSystemDir(dir string) bool {
+	switch dir {
+	case "/bin", "/sbin", "/usr/bin", "/usr/sbin":
+		return true
+	}
+	return false
+}
+
+// checkDirectoryWritable checks that files can be created next to the
+// binary, which is what replacing it requires. Nothing is created.
+func checkDirectoryWritable(path string) []PreflightCheck {
+	dir := filepath.Dir(path)
+	mount := PreflightCheck{Name: "read-only-mount", Status: CheckOK, Detail: dir + " is on a writable filesystem"}
+	writable := PreflightCheck{Name: "directory-writable", Status: CheckOK, Detail: dir + " is writable"}
+
+	err := canWrite(dir)
+	if err == nil {
+		return []PreflightCheck{mount, writable}
+	}
+
+	switch {
+	case errors.Is(err, syscall.EROFS):
+		mount.Status = CheckFail
+		mount.Detail = dir + " is on a read-only filesystem"
+		mount.Remedy = "Install CORE CLI to a writable location such as ~/.local/bin, or update the image it ships in."
+		writable.Status = CheckSkipped
+		writable.Detail = "not checked on a read-only filesystem"
+	case errors.Is(err, fs.ErrPermission):
+		writable.Status = CheckFail
+		writable.Detail = fmt.Sprintf("no permission to write to %s", dir)
+		writable.Remedy = writableRemedy(dir)
+	default:
+		writable.Status = CheckFail
+		writable.Detail = fmt.Sprintf("cannot write to %s: %v", dir, err)
+		writable.Remedy = writableRemedy(dir)
+	}
+	return []PreflightCheck{mount, writable}
+}
+
+// checkBinaryWritable reports binaries the current user cannot write.
+// Replacement renames over the file, so this only warns; it usually means
+// the directory is not writable either.
+func checkBinaryWritable(path string) PreflightCheck {
+	check := PreflightCheck{Name: "binary-writable", Status: CheckOK, Detail: path + " is writable"}
+
+	if err := canWrite(path); err != nil {
+		check.Status = CheckWarn
+		check.Detail = fmt.Sprintf("%s is not writable by the current user", path)
+		check.Remedy = writableRemedy(filepath.Dir(path))
+	}
+	return check
+}
+
+// writableRemedy suggests how to make dir writable for an update.
+func writableRemedy(dir string) string {
+	if runtime.GOOS == "windows" {
+		return "Run the update from an elevated prompt, or reinstall CORE CLI to a folder you own."
+	}
+	return fmt.Sprintf("Run the update with sudo, take ownership with 'sudo chown $USER %s', or reinstall CORE CLI to ~/.local/bin.", dir)
+}
+
+// checkDiskSpace checks that the binary's filesystem has room for the new
+// binary, and the download directory for the asset and the retained backup.
+func checkDiskSpace(path string, config PreflightConfig) PreflightCheck {
+	check := PreflightCheck{Name: "disk-space", Status: CheckOK}
+
+	var current int64
+	if info, err := os.Stat(path); err == nil {
+		current = info.Size()
+	}
+	asset := config.AssetSize
+	if asset <= 0 {
+		asset = current
+	}
+
+	downloadDir := config.StateDir
+	if downloadDir == "" {
+		downloadDir = os.TempDir()
+	}
+	// The state directory may not exist before the first update
+	for downloadDir != filepath.Dir(downloadDir) {
+		if _, err := os.Stat(downloadDir); err == nil {
+			break
+		}
+		downloadDir = filepath.Dir(downloadDir)
+	}
+
+	needs := []struct {
+		dir  string
+		size int64
+	}{
+		// An archive may unpack larger than it downloads
+		{filepath.Dir(path), max(asset, current)},
+		{downloadDir, asset + current},
+	}
+
+	var details []string
+	for _, need := range needs {
+		free, err := freeSpace(need.dir)
+		if err != nil {
+			check.Status = CheckSkipped
+			check.Detail = fmt.Sprintf("cannot determine free space in %s: %v", need.dir, err)
+			return check
+		}
+		details = append(details, fmt.Sprintf("%s free in %s", formatBytes(free), need.dir))
+		if free < uint64(need.size) {
+			check.Status = CheckFail
+			check.Detail = fmt.Sprintf("%s needs %s but has %s free", need.dir, formatBytes(uint64(need.size)), formatBytes(free))
+			check.Remedy = "Free up disk space, or set CORE_STATE_DIR to a filesystem with more room."
+			return check
+		}
+	}
+
+	check.Detail = strings.Join(details, ", ")
+	return check
+}
+
+// formatBytes formats a size in binary units, e.g. 12.3 MiB.
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,26 @@
+//go:build !(linux || darwin || freebsd)
+
+package update
+
+import (
+	"errors"
+	"os"
+)
+
+// freeSpace is not implemented on this platform.
+func freeSpace(dir string) (uint64, error) {
+	return 0, errors.ErrUnsupported
+}
+
+// canWrite approximates write permission from the file's mode bits, for
+// files and directories alike.
+func canWrite(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.Mode().Perm()&0200 == 0 {
+		return os.ErrPermission
+	}
+	return nil
+}
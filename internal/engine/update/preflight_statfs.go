@@ -0,0 +1,30 @@
+//go:build linux || darwin || freebsd
+
+package update
+
+import (
+	"errors"
+	"syscall"
+)
+
+// freeSpace returns the bytes available to unprivileged users on the
+// filesystem holding dir.
+func freeSpace(dir string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, err
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
+
+// canWrite reports whether the current user may write to path, without
+// opening it. It fails with EROFS on a read-only filesystem. A running
+// executable reports ETXTBSY, which does not prevent replacing it by rename.
+func canWrite(path string) error {
+	const wOK = 2
+	err := syscall.Access(path, wOK)
+	if errors.Is(err, syscall.ETXTBSY) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,145 @@
+package update
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// findCheck returns the named check of a report.
+func findCheck(t *testing.T, report PreflightReport, name string) PreflightCheck {
+	t.Helper()
+	for _, check := range report.Checks {
+		if check.Name == name {
+			return check
+		}
+	}
+	t.Fatalf("report has no %q check: %+v", name, report.Checks)
+	return PreflightCheck{}
+}
+
+func TestPreflight_OK(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "core")
+	os.WriteFile(target, []byte("binary"), 0755)
+	before, _ := os.Stat(dir)
+
+	report := Preflight(PreflightConfig{TargetPath: target, AssetSize: 1024, StateDir: t.TempDir()})
+	if err := report.Err(); err != nil {
+		t.Fatalf("Expected preflight to pass, got %v", err)
+	}
+
+	// Probing with a scratch file would change the directory
+	if after, _ := os.Stat(dir); !after.ModTime().Equal(before.ModTime()) {
+		t.Error("Preflight() wrote to the binary's directory")
+	}
+	if report.ResolvedPath != target {
+		t.Errorf("ResolvedPath = %s, want %s", report.ResolvedPath, target)
+	}
+
+	for _, name := range []string{"symlink", "package-manager", "read-only-mount", "directory-writable", "binary-writable", "disk-space"} {
+		if check := findCheck(t, report, name); check.Status == CheckFail {
+			t.Errorf("Check %s failed: %s", name, check.Detail)
+		}
+	}
+}
+
+func TestPreflight_Symlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need privileges on Windows")
+	}
+
+	dir := t.TempDir()
+	target := filepath.Join(dir, "core-1.0.0")
+	link := filepath.Join(dir, "core")
+	os.WriteFile(target, []byte("binary"), 0755)
+	os.Symlink(target, link)
+
+	report := Preflight(PreflightConfig{TargetPath: link})
+	if resolved, _ := filepath.EvalSymlinks(target); report.ResolvedPath != resolved {
+		t.Errorf("ResolvedPath = %s, want %s", report.ResolvedPath, resolved)
+	}
+	if check := findCheck(t, report, "symlink"); check.Status != CheckWarn {
+		t.Errorf("Expected a symlink warning, got %+v", check)
+	}
+
+	os.Remove(target)
+	report = Preflight(PreflightConfig{TargetPath: link})
+	if !errors.Is(report.Err(), ErrPreflightFailed) {
+		t.Errorf("Expected a broken symlink to fail, got %v", report.Err())
+	}
+}
+
+func TestPreflight_DirectoryNotWritable(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		t.Skip("needs a Unix user without override permissions")
+	}
+
+	dir := t.TempDir()
+	target := filepath.Join(dir, "core")
+	os.WriteFile(target, []byte("binary"), 0555)
+	os.Chmod(dir, 0555)
+	defer os.Chmod(dir, 0755)
+
+	report := Preflight(PreflightConfig{TargetPath: target})
+	check := findCheck(t, report, "directory-writable")
+	if check.Status != CheckFail || check.Remedy == "" {
+		t.Errorf("Expected a failure with a remedy, got %+v", check)
+	}
+	if check := findCheck(t, report, "binary-writable"); check.Status != CheckWarn {
+		t.Errorf("Expected a read-only binary warning, got %+v", check)
+	}
+}
+
+func TestPreflight_DiskSpace(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "core")
+	os.WriteFile(target, []byte("binary"), 0755)
+
+	report := Preflight(PreflightConfig{TargetPath: target, AssetSize: 1 << 62})
+	check := findCheck(t, report, "disk-space")
+	if check.Status == CheckSkipped {
+		t.Skip("free space is not available on this platform")
+	}
+	if check.Status != CheckFail || check.Remedy == "" {
+		t.Errorf("Expected an exabyte download to fail, got %+v", check)
+	}
+}
+
+func TestDetectPackageManager(t *testing.T) {
+	infoDir := t.TempDir()
+	os.WriteFile(filepath.Join(infoDir, "bash.list"), []byte("/usr/bin/bash\n"), 0644)
+	os.WriteFile(filepath.Join(infoDir, "core-cli:amd64.list"), []byte("/.\n/usr\n/usr/bin\n/usr/bin/core\n"), 0644)
+
+	saved := dpkgInfoDir
+	dpkgInfoDir = infoDir
+	defer func() { dpkgInfoDir = saved }()
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/opt/homebrew/Cellar/core/1.2.0/bin/core", "Homebrew"},
+		{"/home/linuxbrew/.linuxbrew/bin/core", "Homebrew"},
+		{"/nix/store/0abc-core-1.2.0/bin/core", "Nix"},
+		{`C:\Users\me\scoop\apps\core\current\core.exe`, "Scoop"},
+		{"/usr/bin/core", "apt (package core-cli)"},
+		{"/usr/sbin/core", ""},
+		{"/usr/local/bin/core", ""},
+		{"/home/me/.local/bin/core", ""},
+	}
+
+	for _, tt := range tests {
+		got := ""
+		if pm := detectPackageManager(tt.path); pm != nil {
+			got = pm.name
+			if pm.remedy == "" {
+				t.Errorf("%s: no remedy for %s", tt.path, pm.name)
+			}
+		}
+		if got != tt.want {
+			t.Errorf("detectPackageManager(%s) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package update
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/Tfc538/core-cli/internal/version"
+)
+
+// ErrSelfTestFailed is returned when the installed binary does not run or
+// reports the wrong version. The previous binary has been put back.
+var ErrSelfTestFailed = errors.New("installed binary failed self-test")
+
+const (
+	defaultSelfTestTimeout = 10 * time.Second
+	// selfTestOutputLimit caps what is read from the new binary.
+	selfTestOutputLimit = 64 << 10
+)
+
+// selfTest runs the installed binary with `version --json` and checks that
+// it reports the expected version.
+func (u *Updater) selfTest() error {
+	timeout := u.config.SelfTestTimeout
+	if timeout <= 0 {
+		timeout = defaultSelfTestTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	sandbox, err := os.MkdirTemp("", "core-selftest-*")
+	if err != nil {
+		return fmt.Errorf("failed to create self-test directory: %w", err)
+	}
+	defer os.RemoveAll(sandbox)
+
+	cmd := exec.CommandContext(ctx, u.config.TargetPath, "version", "--json")
+	cmd.Dir = sandbox
+	cmd.Env = selfTestEnv(sandbox)
+	stdout := &limitedBuffer{limit: selfTestOutputLimit}
+	stderr := &limitedBuffer{limit: selfTestOutputLimit}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	// Don't wait for grandchildren holding the pipes after a timeout
+	cmd.WaitDelay = time.Second
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("%w: no response within %s", ErrSelfTestFailed, timeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%w: %v: %s", ErrSelfTestFailed, err, msg)
+		}
+		return fmt.Errorf("%w: %v", ErrSelfTestFailed, err)
+	}
+
+	var info version.Info
+	if err := json.Unmarshal(stdout.Bytes(), &info); err != nil {
+		return fmt.Errorf("%w: invalid version output: %v", ErrSelfTestFailed, err)
+	}
+
+	if NormalizeVersion(info.Version) != NormalizeVersion(u.config.ExpectedVersion) {
+		return fmt.Errorf("%w: reports version %q, expected %s", ErrSelfTestFailed, info.Version, u.config.ExpectedVersion)
+	}
+
+	return nil
+}
+
+// selfTestEnv returns a minimal environment that points every CORE
+// directory into the sandbox, so the new binary cannot touch user state.
+func selfTestEnv(sandbox string) []string {
+	env := []string{
+		"CORE_CONFIG_DIR=" + sandbox,
+		"CORE_CACHE_DIR=" + sandbox,
+		"CORE_STATE_DIR=" + sandbox,
+		"HOME=" + sandbox,
+	}
+
+	keep := []string{"PATH", "TMPDIR"}
+	if runtime.GOOS == "windows" {
+		// Windows processes fail to start without these
+		keep = append(keep, "SYSTEMROOT", "WINDIR", "TEMP", "TMP", "USERPROFILE")
+	}
+	for _, key := range keep {
+		if value, ok := os.LookupEnv(key); ok {
+			env = append(env, key+"="+value)
+		}
+	}
+
+	return env
+}
+
+// rollbackInstall puts the binary saved at oldPath back in place of a
+// failed install. The failed binary is not running, so a plain rename
+// replaces it on every platform.
+func (u *Updater) rollbackInstall(oldPath string) error {
+	if err := os.Rename(oldPath, u.config.TargetPath); err != nil {
+		return fmt.Errorf("failed to restore previous binary from %s: %w", oldPath, err)
+	}
+	return nil
+}
+
+// limitedBuffer keeps at most limit bytes and silently drops the rest.
+type limitedBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+// Write implements io.Writer.
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.Len(); room > 0 {
+		b.Buffer.Write(p[:min(len(p), room)])
+	}
+	return len(p), nil
+}
@@ -0,0 +1,135 @@
+package update
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+)
+
+// fakeBinary returns a shell script standing in for a release binary.
+func fakeBinary(t *testing.T, body string) []byte {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("self-test fixtures are shell scripts")
+	}
+	return []byte("#!/bin/sh\n" + body + "\n")
+}
+
+func TestUpdater_Apply_SelfTest(t *testing.T) {
+	versionJSON := `echo '{"version":"%s","commit":"abc123","build_date":"2026-01-01T00:00:00Z"}'`
+
+	tests := []struct {
+		name    string
+		binary  string
+		timeout time.Duration
+		wantErr bool
+	}{
+		{"reports expected version", fmt.Sprintf(versionJSON, "1.2.0"), 0, false},
+		{"reports other version", fmt.Sprintf(versionJSON, "1.1.0"), 0, true},
+		{"exits with error", "echo 'exec format error' >&2; exit 1", 0, true},
+		{"prints no JSON", "echo 'CORE CLI v1.2.0'", 0, true},
+		{"hangs", "sleep 5", 100 * time.Millisecond, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := fakeBinary(t, tt.binary)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write(content)
+			}))
+			defer server.Close()
+
+			stateDir := t.TempDir()
+			target := filepath.Join(t.TempDir(), "core")
+			os.WriteFile(target, []byte("old binary"), 0755)
+
+			updater := NewUpdater(UpdaterConfig{
+				DownloadURL:     server.URL,
+				TargetPath:      target,
+				ChecksumPolicy:  ChecksumSkip,
+				StateDir:        stateDir,
+				CurrentVersion:  "1.0.0",
+				ExpectedVersion: "1.2.0",
+				SelfTestTimeout: tt.timeout,
+			})
+
+			var stages []Stage
+			updater.SetProgressCallback(func(p UpdateProgress) {
+				stages = append(stages, p.Stage)
+			})
+
+			start := time.Now()
+			err := updater.Apply()
+			if time.Since(start) > 4*time.Second {
+				t.Error("Self-test did not honour its timeout")
+			}
+
+			if !slices.Contains(stages, "verifying-install") {
+				t.Errorf("Expected a verifying-install stage, got %v", stages)
+			}
+
+			installed, _ := os.ReadFile(target)
+			_, retainErr := NewHistory(stateDir, 0).Find("1.0.0")
+
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("Apply() failed: %v", err)
+				}
+				if string(installed) != string(content) {
+					t.Error("Expected the new binary to stay installed")
+				}
+				if retainErr != nil {
+					t.Errorf("Expected the previous binary to be retained: %v", retainErr)
+				}
+				return
+			}
+
+			if !errors.Is(err, ErrSelfTestFailed) {
+				t.Fatalf("Expected ErrSelfTestFailed, got %v", err)
+			}
+			if string(installed) != "old binary" {
+				t.Errorf("Expected the previous binary to be restored, got %q", installed)
+			}
+			if !errors.Is(retainErr, ErrNoBackup) {
+				t.Errorf("Expected nothing retained after a rollback, got %v", retainErr)
+			}
+			if _, err := os.Stat(oldSavePath(target)); !os.IsNotExist(err) {
+				t.Error("Expected no leftover .old file")
+			}
+		})
+	}
+}
+
+func TestUpdater_Apply_SelfTestWithoutStateDir(t *testing.T) {
+	content := fakeBinary(t, `echo '{"version":"v1.2.0"}'`)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(content)
+	}))
+	defer server.Close()
+
+	target := filepath.Join(t.TempDir(), "core")
+	os.WriteFile(target, []byte("old binary"), 0755)
+
+	updater := NewUpdater(UpdaterConfig{
+		DownloadURL:     server.URL,
+		TargetPath:      target,
+		ChecksumPolicy:  ChecksumSkip,
+		ExpectedVersion: "1.2.0",
+	})
+
+	if err := updater.Apply(); err != nil {
+		t.Fatalf("Apply() failed: %v", err)
+	}
+
+	// Without a state directory the saved binary is only kept for the test
+	if _, err := os.Stat(oldSavePath(target)); !os.IsNotExist(err) {
+		t.Error("Expected the previous binary to be removed after a passing self-test")
+	}
+}
@@ -0,0 +1,94 @@
+package update
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"aead.dev/minisign"
+)
+
+// ErrSignatureMissing is returned when a public key is configured but the
+// release does not provide a detached signature to verify against.
+var ErrSignatureMissing = errors.New("release does not provide a minisign signature")
+
+// ErrSignatureInvalid is returned when a detached signature does not match
+// the signed content or was produced by a different key.
+var ErrSignatureInvalid = errors.New("minisign signature verification failed")
+
+// parsePublicKey decodes a minisign public key. Both the bare base64 line and
+// the two-line format written by `minisign -G` are accepted.
+func parsePublicKey(text string) (minisign.PublicKey, error) {
+	var key minisign.PublicKey
+	if err := key.UnmarshalText([]byte(strings.TrimSpace(text))); err != nil {
+		return minisign.PublicKey{}, fmt.Errorf("invalid update public key: %w", err)
+	}
+	return key, nil
+}
+
+// verifySignature checks the downloaded release against its detached
+// minisign signature. A signature over the asset itself is preferred; when
+// only checksums.txt is signed, the signed checksum list must contain a
+// matching hash for the asset. Verification is skipped when no public key is
+// embedded (e.g. development builds).
+func (u *Updater) verifySignature(ctx context.Context, filePath string) error {
+	if strings.TrimSpace(u.config.PublicKey) == "" {
+		return nil
+	}
+
+	key, err := parsePublicKey(u.config.PublicKey)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case u.config.SignatureURL != "":
+		signature, err := u.fetch(ctx, u.config.SignatureURL)
+		if err != nil {
+			return fmt.Errorf("failed to download signature: %w", err)
+		}
+
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return fmt.Errorf("failed to read downloaded file: %w", err)
+		}
+
+		if !minisign.Verify(key, content, signature) {
+			return ErrSignatureInvalid
+		}
+		return nil
+
+	case u.config.ChecksumSignatureURL != "" && u.config.ChecksumURL != "":
+		signature, err := u.fetch(ctx, u.config.ChecksumSignatureURL)
+		if err != nil {
+			return fmt.Errorf("failed to download checksum signature: %w", err)
+		}
+
+		checksums, err := u.fetch(ctx, u.config.ChecksumURL)
+		if err != nil {
+			return fmt.Errorf("failed to download checksum file: %w", err)
+		}
+
+		if !minisign.Verify(key, checksums, signature) {
+			return ErrSignatureInvalid
+		}
+
+		expectedHash := u.parseChecksum(string(checksums), u.assetName())
+		if expectedHash == "" {
+			return fmt.Errorf("%w: signed checksum file has no entry for %s", ErrSignatureInvalid, u.assetName())
+		}
+
+		actualHash, err := fileSHA256(filePath)
+		if err != nil {
+			return err
+		}
+		if actualHash != expectedHash {
+			return fmt.Errorf("%w: checksum mismatch: expected %s, got %s", ErrSignatureInvalid, expectedHash, actualHash)
+		}
+		return nil
+	}
+
+	return ErrSignatureMissing
+}
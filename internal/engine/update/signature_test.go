@@ -0,0 +1,135 @@
+package update
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"aead.dev/minisign"
+)
+
+func newTestKey(t *testing.T) (string, minisign.PrivateKey) {
+	t.Helper()
+
+	publicKey, privateKey, err := minisign.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	return publicKey.String(), privateKey
+}
+
+func TestUpdater_VerifySignature_Asset(t *testing.T) {
+	publicKey, privateKey := newTestKey(t)
+	content := []byte("signed binary content")
+	signature := minisign.Sign(privateKey, content)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(signature)
+	}))
+	defer server.Close()
+
+	testFile := t.TempDir() + "/core-linux-amd64"
+	os.WriteFile(testFile, content, 0644)
+
+	updater := NewUpdater(UpdaterConfig{
+		DownloadURL:  "https://example.com/core-linux-amd64",
+		SignatureURL: server.URL,
+		PublicKey:    publicKey,
+	})
+
+	if err := updater.verifySignature(context.Background(), testFile); err != nil {
+		t.Fatalf("verifySignature() failed: %v", err)
+	}
+
+	// Tampered content must be rejected
+	os.WriteFile(testFile, []byte("tampered binary content"), 0644)
+	if err := updater.verifySignature(context.Background(), testFile); !errors.Is(err, ErrSignatureInvalid) {
+		t.Errorf("expected ErrSignatureInvalid, got %v", err)
+	}
+}
+
+func TestUpdater_VerifySignature_Checksums(t *testing.T) {
+	publicKey, privateKey := newTestKey(t)
+	content := []byte("binary covered by signed checksums")
+	checksums := []byte(fmt.Sprintf("%x  core-linux-amd64\n", sha256.Sum256(content)))
+	signature := minisign.Sign(privateKey, checksums)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/checksums.txt":
+			w.Write(checksums)
+		case "/checksums.txt.minisig":
+			w.Write(signature)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	testFile := t.TempDir() + "/download"
+	os.WriteFile(testFile, content, 0644)
+
+	updater := NewUpdater(UpdaterConfig{
+		DownloadURL:          "https://example.com/download/core-linux-amd64",
+		ChecksumURL:          server.URL + "/checksums.txt",
+		ChecksumSignatureURL: server.URL + "/checksums.txt.minisig",
+		PublicKey:            publicKey,
+	})
+
+	if err := updater.verifySignature(context.Background(), testFile); err != nil {
+		t.Fatalf("verifySignature() failed: %v", err)
+	}
+
+	// A signature from another key must be rejected
+	otherKey, _ := newTestKey(t)
+	updater.config.PublicKey = otherKey
+	if err := updater.verifySignature(context.Background(), testFile); !errors.Is(err, ErrSignatureInvalid) {
+		t.Errorf("expected ErrSignatureInvalid, got %v", err)
+	}
+}
+
+func TestUpdater_VerifySignature_Missing(t *testing.T) {
+	publicKey, _ := newTestKey(t)
+	testFile := t.TempDir() + "/core"
+	os.WriteFile(testFile, []byte("content"), 0644)
+
+	updater := NewUpdater(UpdaterConfig{PublicKey: publicKey})
+	if err := updater.verifySignature(context.Background(), testFile); !errors.Is(err, ErrSignatureMissing) {
+		t.Errorf("expected ErrSignatureMissing, got %v", err)
+	}
+
+	// Without an embedded key verification is skipped
+	updater = NewUpdater(UpdaterConfig{})
+	if err := updater.verifySignature(context.Background(), testFile); err != nil {
+		t.Errorf("expected no error without public key, got %v", err)
+	}
+}
+
+func TestChecker_FindSignatureURLs(t *testing.T) {
+	checker := NewChecker(CheckerConfig{})
+
+	release := &Release{
+		Assets: []Asset{
+			{Name: "core-linux-amd64", DownloadURL: "https://example.com/core-linux-amd64"},
+			{Name: "core-linux-amd64.minisig", DownloadURL: "https://example.com/core-linux-amd64.minisig"},
+			{Name: "checksums.txt", DownloadURL: "https://example.com/checksums.txt"},
+			{Name: "checksums.txt.minisig", DownloadURL: "https://example.com/checksums.txt.minisig"},
+		},
+	}
+
+	signatureURL, checksumSignatureURL := checker.findSignatureURLs(release,
+		"https://example.com/core-linux-amd64", "https://example.com/checksums.txt")
+
+	if signatureURL != "https://example.com/core-linux-amd64.minisig" {
+		t.Errorf("unexpected signature URL: %s", signatureURL)
+	}
+	if checksumSignatureURL != "https://example.com/checksums.txt.minisig" {
+		t.Errorf("unexpected checksum signature URL: %s", checksumSignatureURL)
+	}
+}
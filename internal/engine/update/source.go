@@ -0,0 +1,246 @@
+package update
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Release is a published release, independent of the host serving it.
+type Release struct {
+	TagName    string
+	Body       string
+	Draft      bool
+	Prerelease bool
+	Assets     []Asset
+
+	// Channel, when set, overrides the channel implied by the version.
+	Channel Channel
+	// Metadata holds compatibility information published inline by the
+	// source, e.g. in a release manifest.
+	Metadata ReleaseMetadata
+}
+
+// Asset is a downloadable file attached to a release. Size, SHA256 and
+// SignatureURL are only known to sources that publish them per asset.
+type Asset struct {
+	Name         string
+	DownloadURL  string
+	Size         int64
+	SHA256       string
+	SignatureURL string
+
+	// OS and Arch are the platform the source declares the asset is built
+	// for. Only manifests declare them; assets of other sources are matched
+	// by name alone.
+	OS   string
+	Arch string
+
+	// ID and APIURL identify the asset in the host's API, which serves
+	// assets of private repositories to authenticated requests.
+	ID     int64
+	APIURL string
+}
+
+// ReleaseSource lists the releases published on a release host. Checker
+// works only against this interface, so supporting another host does not
+// touch version selection or comparison.
+type ReleaseSource interface {
+	// Name identifies the host in messages, e.g. "GitHub".
+	Name() string
+	// LatestRelease returns the newest stable release.
+	LatestRelease(ctx context.Context) (*Release, error)
+	// ReleaseByTag returns the release published under a tag such as v1.2.0,
+	// or ErrReleaseNotFound when the repository has no release under it.
+	ReleaseByTag(ctx context.Context, tag string) (*Release, error)
+	// ListReleases returns recent releases, including prereleases.
+	ListReleases(ctx context.Context) ([]Release, error)
+}
+
+// endpointSource is implemented by the built-in sources to name the URL a
+// check starts from.
+type endpointSource interface {
+	endpoint() string
+}
+
+// SourceType selects a built-in ReleaseSource.
+type SourceType string
+
+const (
+	SourceGitHub   SourceType = "github"
+	SourceGitLab   SourceType = "gitlab"
+	SourceGitea    SourceType = "gitea"
+	SourceManifest SourceType = "manifest"
+)
+
+// SourceTypes lists the built-in release sources.
+var SourceTypes = []SourceType{SourceGitHub, SourceGitLab, SourceGitea, SourceManifest}
+
+// ParseSourceType validates a source name. An empty name selects GitHub.
+func ParseSourceType(name string) (SourceType, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return SourceGitHub, nil
+	}
+
+	for _, s := range SourceTypes {
+		if string(s) == name {
+			return s, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown release source %q (expected github, gitlab, gitea or manifest)", name)
+}
+
+// newReleaseSource builds the release source selected by the configuration.
+func newReleaseSource(config CheckerConfig, f *fetcher) (ReleaseSource, error) {
+	if config.Source != nil {
+		return config.Source, nil
+	}
+
+	sourceType, err := ParseSourceType(string(config.SourceType))
+	if err != nil {
+		return nil, err
+	}
+
+	switch sourceType {
+	case SourceGitLab:
+		return newGitLabSource(config, f), nil
+	case SourceGitea:
+		return newGiteaSource(config, f), nil
+	case SourceManifest:
+		return newManifestSource(config, f), nil
+	default:
+		return newGitHubSource(config, f), nil
+	}
+}
+
+// fetcher performs the HTTP requests of the checker and its release source
+// through the update-check cache.
+type fetcher struct {
+	client  *http.Client
+	cache   *checkCache
+	ttl     time.Duration
+	refresh bool
+}
+
+// newFetcher creates the fetcher for a checker configuration.
+func newFetcher(config CheckerConfig) *fetcher {
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	return &fetcher{
+		client: &http.Client{
+			Transport:     newTransport(config.Transport),
+			Timeout:       timeout,
+			CheckRedirect: checkRedirect,
+		},
+		cache:   newCheckCache(config.CacheDir),
+		ttl:     config.CacheTTL,
+		refresh: config.Refresh,
+	}
+}
+
+// get performs req through the update-check cache. Fresh cached responses
+// are returned without a network call, stale ones are revalidated with
+// If-None-Match, and a 304 is served from the cache. The response header is
+// nil for cached responses.
+func (f *fetcher) get(req *http.Request) (int, http.Header, []byte, error) {
+	key := req.URL.String()
+
+	cached, ok := f.cache.get(key)
+	// Local files are cheap to read and have no validators
+	if f.refresh || req.URL.Scheme == "file" {
+		ok = false
+	}
+	if ok && cached.fresh(f.ttl) {
+		return http.StatusOK, nil, cached.Body, nil
+	}
+	if ok && cached.ETag != "" {
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && ok {
+		cached.FetchedAt = time.Now()
+		// The cache is an optimisation; a failed write only costs a request
+		_ = f.cache.put(key, cached)
+		return http.StatusOK, nil, cached.Body, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	if resp.StatusCode == http.StatusOK && req.URL.Scheme != "file" && json.Valid(body) {
+		_ = f.cache.put(key, cacheEntry{
+			ETag:      resp.Header.Get("ETag"),
+			FetchedAt: time.Now(),
+			Body:      body,
+		})
+	}
+
+	return resp.StatusCode, resp.Header, body, nil
+}
+
+// releaseNotFound reclassifies the 404 of a release-by-tag request as
+// ErrReleaseNotFound when the repository at repoURL is readable. Hosts
+// answer 404 for missing and private repositories too, which must not be
+// mistaken for a tag that is not published yet.
+func (f *fetcher) releaseNotFound(ctx context.Context, host, repoURL string, header http.Header, err error) error {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Err != ErrRepoNotFound {
+		return err
+	}
+
+	var repo json.RawMessage
+	if f.getJSON(ctx, host, repoURL, header, &repo) == nil {
+		apiErr.Err = ErrReleaseNotFound
+	}
+	return err
+}
+
+// getJSON fetches url with the given headers and decodes the JSON response
+// into v. host names the API in error messages.
+func (f *fetcher) getJSON(ctx context.Context, host, url string, header http.Header, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create %s request: %w", host, err)
+	}
+	for key, values := range header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+
+	status, respHeader, body, err := f.get(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("failed to fetch %s release: %w", host, err)
+		}
+		return &APIError{Host: host, Err: ErrNetwork, cause: err}
+	}
+
+	if status != http.StatusOK {
+		return newAPIError(host, status, respHeader, body)
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to parse %s response: %w", host, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,198 @@
+package update
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+)
+
+func TestParseSourceType(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    SourceType
+		wantErr bool
+	}{
+		{"", SourceGitHub, false},
+		{"GitLab", SourceGitLab, false},
+		{" gitea ", SourceGitea, false},
+		{"manifest", SourceManifest, false},
+		{"bitbucket", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseSourceType(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseSourceType(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("ParseSourceType(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestChecker_GitLabSource(t *testing.T) {
+	binary := "core-" + runtime.GOOS + "-" + runtime.GOARCH
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/api/v4/projects/group%2Fsub%2Fcore/releases" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("PRIVATE-TOKEN") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`[
+			{"tag_name": "v1.3.0-beta.1", "assets": {"links": []}},
+			{"tag_name": "v1.2.0", "description": "notes", "assets": {"links": [
+				{"name": "` + binary + `", "url": "https://gitlab.example.com/link", "direct_asset_url": "https://gitlab.example.com/direct"}
+			]}}
+		]`))
+	}))
+	defer server.Close()
+
+	checker := NewChecker(CheckerConfig{
+		GitHubOwner:    "group/sub",
+		GitHubRepo:     "core",
+		CurrentVersion: "1.0.0",
+		SourceType:     SourceGitLab,
+		SourceURL:      server.URL + "/api/v4",
+		SourceToken:    "secret",
+	})
+
+	info, err := checker.Check()
+	if err != nil {
+		t.Fatalf("Check() failed: %v", err)
+	}
+	if info.LatestVersion != "1.2.0" {
+		t.Errorf("Expected latest stable 1.2.0, got %s", info.LatestVersion)
+	}
+	if info.DownloadURL != "https://gitlab.example.com/direct" {
+		t.Errorf("Expected direct asset URL, got %s", info.DownloadURL)
+	}
+	if info.ReleaseNotes != "notes" {
+		t.Errorf("Expected release notes from description, got %q", info.ReleaseNotes)
+	}
+}
+
+func TestChecker_GiteaSource(t *testing.T) {
+	var gotAuth string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		switch r.URL.Path {
+		case "/api/v1/repos/forks/core-cli/releases/latest":
+			json.NewEncoder(w).Encode(GitHubRelease{TagName: "v1.1.0"})
+		case "/api/v1/repos/forks/core-cli/releases/tags/v0.9.0":
+			json.NewEncoder(w).Encode(GitHubRelease{TagName: "v0.9.0"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	checker := NewChecker(CheckerConfig{
+		GitHubOwner:    "forks",
+		GitHubRepo:     "core-cli",
+		CurrentVersion: "1.0.0",
+		SourceType:     SourceGitea,
+		SourceURL:      server.URL + "/api/v1",
+		SourceToken:    "secret",
+	})
+
+	info, err := checker.Check()
+	if err != nil {
+		t.Fatalf("Check() failed: %v", err)
+	}
+	if !info.UpdateAvailable || info.LatestVersion != "1.1.0" {
+		t.Errorf("Expected update to 1.1.0, got %+v", info)
+	}
+	if gotAuth != "token secret" {
+		t.Errorf("Expected Gitea token auth, got %q", gotAuth)
+	}
+
+	info, err = checker.CheckVersion("0.9.0")
+	if err != nil {
+		t.Fatalf("CheckVersion() failed: %v", err)
+	}
+	if info.Direction != DirectionDowngrade {
+		t.Errorf("Expected downgrade, got %s", info.Direction)
+	}
+}
+
+func TestChecker_ManifestSource(t *testing.T) {
+	binary := "core-" + runtime.GOOS + "-" + runtime.GOARCH
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/core/manifest.json" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"releases": [
+			{"version": "1.1.0", "assets": [{"name": "` + binary + `", "url": "v1.1.0/` + binary + `"}]},
+			{"version": "1.2.0-beta.1", "assets": []}
+		]}`))
+	}))
+	defer server.Close()
+
+	checker := NewChecker(CheckerConfig{
+		CurrentVersion: "1.0.0",
+		SourceType:     SourceManifest,
+		SourceURL:      server.URL + "/core/manifest.json",
+		Transport:      server.Client().Transport,
+	})
+
+	info, err := checker.Check()
+	if err != nil {
+		t.Fatalf("Check() failed: %v", err)
+	}
+	if info.LatestVersion != "1.1.0" {
+		t.Errorf("Expected 1.1.0, got %s", info.LatestVersion)
+	}
+	if want := server.URL + "/core/v1.1.0/" + binary; info.DownloadURL != want {
+		t.Errorf("Expected relative asset URL resolved to %s, got %s", want, info.DownloadURL)
+	}
+}
+
+// staticSource is a ReleaseSource serving fixed releases.
+type staticSource []Release
+
+func (s staticSource) Name() string { return "static" }
+
+func (s staticSource) LatestRelease(ctx context.Context) (*Release, error) {
+	return highestRelease(s, ChannelStable)
+}
+
+func (s staticSource) ReleaseByTag(ctx context.Context, tag string) (*Release, error) {
+	return highestRelease(s, ChannelNightly)
+}
+
+func (s staticSource) ListReleases(ctx context.Context) ([]Release, error) {
+	return s, nil
+}
+
+func TestChecker_CustomSource(t *testing.T) {
+	checker := NewChecker(CheckerConfig{
+		CurrentVersion: "1.0.0",
+		Channel:        ChannelBeta,
+		Source:         staticSource{{TagName: "v1.0.0"}, {TagName: "v1.1.0-beta.1"}},
+	})
+
+	info, err := checker.Check()
+	if err != nil {
+		t.Fatalf("Check() failed: %v", err)
+	}
+	if info.LatestVersion != "1.1.0-beta.1" {
+		t.Errorf("Expected beta from custom source, got %s", info.LatestVersion)
+	}
+}
+
+func TestChecker_InvalidSourceType(t *testing.T) {
+	checker := NewChecker(CheckerConfig{SourceType: "bitbucket"})
+	if _, err := checker.Check(); err == nil {
+		t.Error("Expected an error for an unknown source type")
+	}
+}
@@ -0,0 +1,86 @@
+package update
+
+// Stage is the step an update has reached, as reported in UpdateProgress.
+//
+// Stages follow this state machine; transitions not listed are bugs:
+//
+//	(start)           → downloading, resuming, failed
+//	downloading       → downloading, resuming, retrying, fallback, verifying,
+//	                    extracting, replacing, complete, failed
+//	resuming          → downloading, retrying, fallback, failed
+//	retrying          → downloading, resuming, retrying, fallback, failed
+//	fallback          → downloading, resuming, retrying, fallback, failed
+//	verifying         → verified, extracting, replacing, complete, failed
+//	verified          → extracting, replacing, complete, failed
+//	extracting        → replacing, failed
+//	replacing         → verifying-install, complete, failed
+//	verifying-install → complete, failed
+//
+// complete and failed are terminal. verifying is skipped when checksums are
+// not checked, verified when no checksum was available (a warning says so),
+// extracting for raw binaries, and verifying-install without a self-test.
+// Offline bundles complete straight after verification. StageWarning may
+// be reported at any point before the end and leaves the stage unchanged.
+type Stage string
+
+const (
+	// StageDownloading reports download progress, once per chunk.
+	StageDownloading Stage = "downloading"
+	// StageResuming continues a partial download from a previous run.
+	StageResuming Stage = "resuming"
+	// StageRetrying repeats an interrupted download; Attempt counts retries.
+	StageRetrying Stage = "retrying"
+	// StageFallback moves on to the next mirror; Mirror names it.
+	StageFallback Stage = "fallback"
+	// StageVerifying starts checking the download against its checksum.
+	StageVerifying Stage = "verifying"
+	// StageVerified reports that the checksum matched.
+	StageVerified Stage = "verified"
+	// StageWarning reports a problem that does not stop the update.
+	StageWarning Stage = "warning"
+	// StageExtracting unpacks the binary from an archive.
+	StageExtracting Stage = "extracting"
+	// StageReplacing swaps the new binary in.
+	StageReplacing Stage = "replacing"
+	// StageVerifyingInstall runs the self-test of the installed binary.
+	StageVerifyingInstall Stage = "verifying-install"
+	// StageComplete ends a successful update.
+	StageComplete Stage = "complete"
+	// StageFailed ends a failed update; Error says why.
+	StageFailed Stage = "failed"
+)
+
+// stageTransitions lists the stages allowed to follow each stage. The
+// empty stage is the start of an update.
+var stageTransitions = map[Stage][]Stage{
+	"": {StageDownloading, StageResuming, StageFailed},
+	StageDownloading: {StageDownloading, StageResuming, StageRetrying, StageFallback, StageVerifying,
+		StageExtracting, StageReplacing, StageComplete, StageFailed},
+	StageResuming:         {StageDownloading, StageRetrying, StageFallback, StageFailed},
+	StageRetrying:         {StageDownloading, StageResuming, StageRetrying, StageFallback, StageFailed},
+	StageFallback:         {StageDownloading, StageResuming, StageRetrying, StageFallback, StageFailed},
+	StageVerifying:        {StageVerified, StageExtracting, StageReplacing, StageComplete, StageFailed},
+	StageVerified:         {StageExtracting, StageReplacing, StageComplete, StageFailed},
+	StageExtracting:       {StageReplacing, StageFailed},
+	StageReplacing:        {StageVerifyingInstall, StageComplete, StageFailed},
+	StageVerifyingInstall: {StageComplete, StageFailed},
+}
+
+// Terminal reports whether the update is over.
+func (s Stage) Terminal() bool {
+	return s == StageComplete || s == StageFailed
+}
+
+// CanTransition reports whether next may follow s in the state machine.
+// Warnings may follow any stage but the terminal ones.
+func (s Stage) CanTransition(next Stage) bool {
+	if next == StageWarning {
+		return !s.Terminal()
+	}
+	for _, allowed := range stageTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,190 @@
+package update
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestStage_CanTransition(t *testing.T) {
+	tests := []struct {
+		from, to Stage
+		want     bool
+	}{
+		{"", StageDownloading, true},
+		{"", StageReplacing, false},
+		{StageDownloading, StageVerifying, true},
+		{StageVerifying, StageVerified, true},
+		{StageVerified, StageVerifying, false},
+		{StageReplacing, StageVerifyingInstall, true},
+		{StageExtracting, StageVerifying, false},
+		{StageVerifying, StageWarning, true},
+		{StageComplete, StageWarning, false},
+		{StageFailed, StageDownloading, false},
+		{StageRetrying, StageFallback, true},
+		{StageFallback, StageRetrying, true},
+	}
+
+	for _, tt := range tests {
+		if got := tt.from.CanTransition(tt.to); got != tt.want {
+			t.Errorf("%q.CanTransition(%q) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestUpdater_StageTransitions(t *testing.T) {
+	content := []byte("new binary")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/core-linux-amd64":
+			w.Write(content)
+		case "/checksums.txt":
+			fmt.Fprintf(w, "%x  core-linux-amd64\n", sha256.Sum256(content))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	target := filepath.Join(t.TempDir(), "core")
+	os.WriteFile(target, []byte("old binary"), 0755)
+
+	updater := NewUpdater(UpdaterConfig{
+		DownloadURL: server.URL + "/core-linux-amd64",
+		ChecksumURL: server.URL + "/checksums.txt",
+		TargetPath:  target,
+		StateDir:    t.TempDir(),
+	})
+
+	// Several consumers see the same events without wrapping each other
+	var renderer, logger []UpdateProgress
+	updater.SetProgressCallback(func(p UpdateProgress) { renderer = append(renderer, p) })
+	updater.Subscribe(func(p UpdateProgress) { logger = append(logger, p) })
+
+	if err := updater.Apply(); err != nil {
+		t.Fatalf("Apply() failed: %v", err)
+	}
+
+	if len(renderer) == 0 || len(renderer) != len(logger) {
+		t.Fatalf("Subscribers saw %d and %d events", len(renderer), len(logger))
+	}
+
+	var stages []Stage
+	for i, event := range renderer {
+		if event.Stage != logger[i].Stage || !event.Time.Equal(logger[i].Time) {
+			t.Errorf("Event %d differs between subscribers: %+v, %+v", i, event, logger[i])
+		}
+		if event.Time.IsZero() || (i > 0 && event.Time.Before(renderer[i-1].Time)) {
+			t.Errorf("Event %d has timestamp %v", i, event.Time)
+		}
+		stages = append(stages, event.Stage)
+	}
+	current := checkTransitions(t, renderer)
+
+	for _, want := range []Stage{StageDownloading, StageVerifying, StageVerified, StageReplacing, StageComplete} {
+		if !slices.Contains(stages, want) {
+			t.Errorf("Expected stage %q, got %v", want, stages)
+		}
+	}
+	if !current.Terminal() {
+		t.Errorf("Update ended in non-terminal stage %q", current)
+	}
+}
+
+func TestUpdater_StageTransitions_RetryThenFallback(t *testing.T) {
+	content := []byte("new binary")
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/core-linux-amd64":
+			w.Write(content)
+		case "/checksums.txt":
+			fmt.Fprintf(w, "%x  core-linux-amd64\n", sha256.Sum256(content))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer source.Close()
+
+	// The mirror fails before sending any bytes, so its retries run out and
+	// the download falls back to the source
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer mirror.Close()
+
+	target := filepath.Join(t.TempDir(), "core")
+	os.WriteFile(target, []byte("old binary"), 0755)
+
+	updater := NewUpdater(UpdaterConfig{
+		DownloadURL:    source.URL + "/core-linux-amd64",
+		DownloadURLs:   []string{mirror.URL + "/v1.1.0/core-linux-amd64"},
+		ChecksumURL:    source.URL + "/checksums.txt",
+		TargetPath:     target,
+		StateDir:       t.TempDir(),
+		MaxRetries:     2,
+		RetryBaseDelay: time.Millisecond,
+	})
+
+	var events []UpdateProgress
+	updater.SetProgressCallback(func(p UpdateProgress) { events = append(events, p) })
+
+	if err := updater.Apply(); err != nil {
+		t.Fatalf("Apply() failed: %v", err)
+	}
+
+	var stages []Stage
+	for _, event := range events {
+		stages = append(stages, event.Stage)
+	}
+	i := slices.Index(stages, StageFallback)
+	if i < 1 || stages[i-1] != StageRetrying {
+		t.Errorf("Expected a fallback right after the last retry, got %v", stages)
+	}
+	if current := checkTransitions(t, events); current != StageComplete {
+		t.Errorf("Update ended in stage %q", current)
+	}
+}
+
+// checkTransitions fails the test for every event the state machine does
+// not allow after the previous one, and returns the final stage.
+func checkTransitions(t *testing.T, events []UpdateProgress) Stage {
+	t.Helper()
+
+	current := Stage("")
+	for _, event := range events {
+		if !current.CanTransition(event.Stage) {
+			t.Errorf("Invalid transition %q → %q", current, event.Stage)
+		}
+		if event.Stage != StageWarning {
+			current = event.Stage
+		}
+	}
+	return current
+}
+
+func TestEventBus_Unsubscribe(t *testing.T) {
+	bus := NewEventBus()
+
+	var first, second int
+	unsubscribe := bus.Subscribe(func(UpdateProgress) { first++ })
+	var unsubscribeSelf func()
+	unsubscribeSelf = bus.Subscribe(func(UpdateProgress) {
+		second++
+		unsubscribeSelf()
+	})
+
+	bus.Publish(UpdateProgress{Stage: StageDownloading})
+	unsubscribe()
+	unsubscribe()
+	bus.Publish(UpdateProgress{Stage: StageComplete})
+
+	if first != 1 || second != 1 {
+		t.Errorf("Expected one delivery each, got %d and %d", first, second)
+	}
+}
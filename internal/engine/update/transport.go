@@ -0,0 +1,73 @@
+package update
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// newTransport returns the HTTP transport used for update requests: base,
+// or a clone of http.DefaultTransport, for http and https. It also serves
+// file:// URLs, so releases can be distributed from a local directory or
+// network share.
+func newTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport.(*http.Transport).Clone()
+	}
+	return &fileTransport{
+		base: base,
+		file: http.NewFileTransport(localFS{}),
+	}
+}
+
+// maxRedirects matches the limit of http.Client's default redirect policy.
+const maxRedirects = 10
+
+// checkRedirect is the redirect policy of update requests. It drops the
+// Authorization and GitLab PRIVATE-TOKEN headers once a redirect leaves the
+// host it was sent to, e.g. from api.github.com to the storage host serving
+// a private asset, which must not see the token. http.Client only does so
+// for unrelated domains, and never for PRIVATE-TOKEN.
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	if req.URL.Host != via[0].URL.Host {
+		req.Header.Del("Authorization")
+		req.Header.Del("PRIVATE-TOKEN")
+	}
+	return nil
+}
+
+// fileTransport routes file:// requests to the local filesystem and
+// everything else to base.
+type fileTransport struct {
+	base http.RoundTripper
+	file http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *fileTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme == "file" {
+		return t.file.RoundTrip(req)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// localFS serves absolute paths from the local filesystem. Unlike
+// http.Dir("/") it accepts Windows drive paths such as /C:/dist/core.exe.
+type localFS struct{}
+
+// Open implements http.FileSystem.
+func (localFS) Open(name string) (http.File, error) {
+	if runtime.GOOS == "windows" && len(name) > 2 && name[0] == '/' && name[2] == ':' {
+		name = name[1:]
+	}
+	if strings.Contains(name, "\x00") {
+		return nil, os.ErrNotExist
+	}
+	return os.Open(filepath.FromSlash(name))
+}
@@ -1,16 +1,51 @@
 package update
 
+import (
+	"net/http"
+	"time"
+)
+
 // UpdateInfo contains information about a potential update.
 type UpdateInfo struct {
-	CurrentVersion  string `json:"current_version"`
-	LatestVersion   string `json:"latest_version"`
-	UpdateAvailable bool   `json:"update_available"`
-	Compatible      bool   `json:"compatible"`
-	DownloadURL     string `json:"download_url"`
-	ChecksumURL     string `json:"checksum_url,omitempty"`
-	ReleaseNotes    string `json:"release_notes,omitempty"`
+	CurrentVersion       string    `json:"current_version"`
+	LatestVersion        string    `json:"latest_version"`
+	Channel              Channel   `json:"channel"`
+	UpdateAvailable      bool      `json:"update_available"`
+	Direction            Direction `json:"direction"`
+	Compatible           bool      `json:"compatible"`
+	AssetName            string    `json:"asset_name,omitempty"`
+	DownloadURL          string    `json:"download_url"`
+	DownloadURLs         []string  `json:"download_urls,omitempty"` // Mirrors first, DownloadURL last
+	ChecksumURL          string    `json:"checksum_url,omitempty"`
+	SHA256               string    `json:"sha256,omitempty"` // Expected digest when published per asset
+	Size                 int64     `json:"size,omitempty"`
+	SignatureURL         string    `json:"signature_url,omitempty"`
+	ChecksumSignatureURL string    `json:"checksum_signature_url,omitempty"`
+	// AssetID and the API URLs locate the files in the GitHub API, which
+	// serves them to authenticated requests when the repository is private.
+	AssetID                 int64    `json:"asset_id,omitempty"`
+	AssetAPIURL             string   `json:"asset_api_url,omitempty"`
+	ChecksumAPIURL          string   `json:"checksum_api_url,omitempty"`
+	SignatureAPIURL         string   `json:"signature_api_url,omitempty"`
+	ChecksumSignatureAPIURL string   `json:"checksum_signature_api_url,omitempty"`
+	ReleaseNotes            string   `json:"release_notes,omitempty"`
+	MinUpgradeFrom          string   `json:"min_upgrade_from,omitempty"` // Oldest version that may update directly
+	BreakingChanges         []string `json:"breaking_changes,omitempty"`
+	SteppingStone           string   `json:"stepping_stone,omitempty"` // Intermediate version to install first when incompatible
 }
 
+// Direction describes how installing a release would move the version.
+type Direction string
+
+const (
+	// DirectionUpgrade moves to a newer version.
+	DirectionUpgrade Direction = "upgrade"
+	// DirectionDowngrade moves to an older version.
+	DirectionDowngrade Direction = "downgrade"
+	// DirectionNone reinstalls the current version.
+	DirectionNone Direction = "none"
+)
+
 // CheckerConfig contains configuration for the update checker.
 type CheckerConfig struct {
 	APIBaseURL       string
@@ -18,23 +53,148 @@ type CheckerConfig struct {
 	GitHubOwner      string
 	GitHubRepo       string
 	CurrentVersion   string
-	GitHubToken      string // Optional token for private repos or higher rate limits
+	GitHubToken      string  // Optional token for private repos or higher rate limits
+	Channel          Channel // Release channel to follow (defaults to ChannelStable)
+
+	// SourceType selects the release host (defaults to SourceGitHub). GitLab
+	// and Gitea use GitHubOwner/GitHubRepo as the project path.
+	SourceType  SourceType
+	SourceURL   string        // API root for GitLab/Gitea, or the manifest URL
+	SourceToken string        // Optional token for GitLab/Gitea
+	Source      ReleaseSource // Custom release source; overrides SourceType
+	// AllowInsecureManifest accepts a manifest SourceURL over plain http://,
+	// trusting the checksums it lists although they are not protected in
+	// transit.
+	AllowInsecureManifest bool
+
+	// AssetTemplates are text/template patterns naming the release binary,
+	// tried in order (default DefaultAssetTemplate). They see .Binary,
+	// .Version, .OS, .Arch, .Libc and .Ext, and are rendered for every
+	// architecture, libc and extension variant that runs on Platform.
+	AssetTemplates []string
+	// ChecksumTemplates name the checksum file (default DefaultChecksumAssets).
+	ChecksumTemplates []string
+	BinaryName        string   // .Binary in templates (default "core")
+	Platform          Platform // Target platform (default CurrentPlatform())
+
+	// Mirrors are base URLs serving release assets as <mirror>/v<version>/<asset>.
+	// They are tried after the core backend's mirror and before the source.
+	Mirrors []string
+
+	// CacheDir enables the update-check cache when set. Responses younger
+	// than CacheTTL are served without a network call; older ones are
+	// revalidated with If-None-Match.
+	CacheDir string
+	CacheTTL time.Duration
+	Refresh  bool // Bypass the cache and always fetch fresh responses
+
+	// Transport carries the HTTP requests (defaults to a clone of
+	// http.DefaultTransport). file:// URLs are always served locally.
+	Transport http.RoundTripper
+	Timeout   time.Duration // Per-request timeout (default 10s)
 }
 
 // UpdateProgress represents the progress of a download or update operation.
 type UpdateProgress struct {
-	Stage      string // "downloading", "verifying", "replacing", "complete", "failed"
-	Percent    int    // 0-100
+	Stage      Stage
+	Time       time.Time // When the event was published
+	Percent    int       // 0-100
 	BytesTotal int64
 	BytesDone  int64
-	Error      error
+	Attempt    int    // Retry number for StageRetrying
+	Mirror     string // Host serving the download; for StageFallback, the mirror tried next
+	Error      error  // Set for StageFailed, StageRetrying, StageFallback and StageWarning
 }
 
+// ChecksumPolicy controls how the updater handles releases whose checksum
+// cannot be verified.
+type ChecksumPolicy string
+
+const (
+	// ChecksumRequire aborts the update unless the checksum verifies.
+	ChecksumRequire ChecksumPolicy = "require"
+	// ChecksumWarn reports a StageWarning progress event when no checksum is
+	// available, but still aborts on a mismatch.
+	ChecksumWarn ChecksumPolicy = "warn"
+	// ChecksumSkip does not verify checksums at all.
+	ChecksumSkip ChecksumPolicy = "skip"
+)
+
 // UpdaterConfig contains configuration for the updater.
 type UpdaterConfig struct {
 	DownloadURL string // URL to the binary to download
-	ChecksumURL string // Optional URL to checksum file
-	TargetPath  string // Path to current binary (usually os.Executable())
+	// DownloadURLs are mirrors of DownloadURL in preference order. Only the
+	// binary is fetched from them: checksum and signature files always come
+	// from their own URLs, so a mirror cannot vouch for what it serves.
+	DownloadURLs []string
+	ChecksumURL  string // Optional URL to checksum file
+	TargetPath   string // Path to current binary (usually os.Executable())
+
+	// AssetName is the release asset name used to look up the checksum
+	// (e.g. "core-linux-amd64"). Defaults to the last path segment of DownloadURL.
+	AssetName string
+	// ChecksumPolicy defaults to ChecksumRequire.
+	ChecksumPolicy ChecksumPolicy
+	// SHA256 is the expected hex digest of the asset when the release source
+	// publishes one. It is checked instead of ChecksumURL.
+	SHA256 string
+
+	// SignatureURL is the detached minisign signature of the downloaded asset.
+	SignatureURL string
+	// ChecksumSignatureURL is the detached minisign signature of ChecksumURL.
+	ChecksumSignatureURL string
+	// AssetAPIURL, ChecksumAPIURL and the signature API URLs are the GitHub
+	// API URLs of the files above. With GitHubToken set they are downloaded
+	// from there instead, which private repositories require. The token is
+	// never sent to mirrors, nor to redirect targets on another host.
+	AssetAPIURL             string
+	ChecksumAPIURL          string
+	SignatureAPIURL         string
+	ChecksumSignatureAPIURL string
+	GitHubToken             string
+
+	// SourceToken authenticates downloads of the files above from a GitLab
+	// or Gitea source (SourceType), whose private projects serve release
+	// files only to authenticated requests. It is sent only to files on the
+	// host of SourceURL, the source's API root, never to mirrors nor to
+	// redirect targets on another host.
+	SourceType  SourceType
+	SourceURL   string
+	SourceToken string
+
+	// PublicKey is the minisign public key releases must be signed with.
+	// Signature verification is skipped when empty.
+	PublicKey string
+
+	// StateDir is where replaced binaries are retained for rollback.
+	// No backup is kept when empty.
+	StateDir string
+	// KeepBackups is the number of retained binaries (default 3).
+	KeepBackups int
+	// CurrentVersion and CurrentCommit describe the binary being replaced.
+	CurrentVersion string
+	CurrentCommit  string
+
+	// MaxRetries bounds download retries after the first attempt (default 3).
+	MaxRetries int
+	// RetryBaseDelay is the initial retry backoff, doubled per attempt (default 500ms).
+	RetryBaseDelay time.Duration
+
+	// Transport carries the HTTP requests, as in CheckerConfig.
+	Transport http.RoundTripper
+	Timeout   time.Duration // Per-request timeout (default 5m)
+
+	// ExpectedVersion enables the post-install self-test: the installed
+	// binary must run `version --json` within SelfTestTimeout (default 10s)
+	// and report this version, or the previous binary is put back.
+	ExpectedVersion string
+	SelfTestTimeout time.Duration
+
+	// ModulePath enables validation of the download before install: it must
+	// be an executable for the running OS and architecture whose build info
+	// names this module and, when ExpectedVersion is set, whose version
+	// ldflag matches it.
+	ModulePath string
 }
 
 // ProgressCallback is called to report progress during updates.
@@ -1,43 +1,99 @@
 package update
 
 import (
+	"context"
 	"crypto/sha256"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
-	"path/filepath"
+	"path"
 	"strings"
 	"time"
 
 	"github.com/minio/selfupdate"
 )
 
+var (
+	// ErrChecksumUnavailable is returned when the checksum file is missing or
+	// cannot be downloaded.
+	ErrChecksumUnavailable = errors.New("checksum unavailable")
+	// ErrChecksumNotFound is returned when the checksum file has no entry for
+	// the downloaded asset.
+	ErrChecksumNotFound = errors.New("no checksum entry for asset")
+	// ErrChecksumMismatch is returned when the downloaded file does not match
+	// its published checksum.
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+)
+
 // Updater handles downloading and applying updates.
 type Updater struct {
-	config   UpdaterConfig
-	client   *http.Client
-	progress ProgressCallback
+	config  UpdaterConfig
+	client  *http.Client
+	mirrors *mirrorTracker
+	events  *EventBus
+	// unsetCallback removes the callback of SetProgressCallback
+	unsetCallback func()
+	// backupPath is where the last ApplyContext retained the old binary
+	backupPath string
 }
 
 // NewUpdater creates a new updater.
 func NewUpdater(config UpdaterConfig) *Updater {
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Minute
+	}
+
 	return &Updater{
 		config: config,
 		client: &http.Client{
-			Timeout: 5 * time.Minute,
+			Transport:     newTransport(config.Transport),
+			Timeout:       timeout,
+			CheckRedirect: checkRedirect,
 		},
-		progress: func(UpdateProgress) {}, // Default no-op callback
+		mirrors: newMirrorTracker(config.StateDir),
+		events:  NewEventBus(),
 	}
 }
 
-// SetProgressCallback sets the callback function for progress updates.
+// BackupPath returns where the replaced binary was retained for rollback by
+// the last successful ApplyContext, or "" if it was not kept.
+func (u *Updater) BackupPath() string {
+	return u.backupPath
+}
+
+// Subscribe registers cb for the progress of this updater, alongside any
+// other subscribers. The returned function unsubscribes it.
+func (u *Updater) Subscribe(cb ProgressCallback) (unsubscribe func()) {
+	return u.events.Subscribe(cb)
+}
+
+// SetProgressCallback sets the callback function for progress updates,
+// replacing the one set before. Subscribers are not affected.
 func (u *Updater) SetProgressCallback(cb ProgressCallback) {
-	u.progress = cb
+	if u.unsetCallback != nil {
+		u.unsetCallback()
+	}
+	u.unsetCallback = u.events.Subscribe(cb)
+}
+
+// emit publishes a progress event to the subscribers.
+func (u *Updater) emit(progress UpdateProgress) {
+	u.events.Publish(progress)
 }
 
 // Apply downloads the update and applies it, replacing the current binary.
 func (u *Updater) Apply() error {
+	return u.ApplyContext(context.Background())
+}
+
+// ApplyContext is like Apply but aborts downloads and verification when ctx
+// is done, removing any temporary files. Once the binary replacement has
+// started it runs to completion.
+func (u *Updater) ApplyContext(ctx context.Context) error {
 	if u.config.DownloadURL == "" {
 		return fmt.Errorf("download URL not specified")
 	}
@@ -46,174 +102,319 @@ func (u *Updater) Apply() error {
 		return fmt.Errorf("target path not specified")
 	}
 
+	lock, err := u.lock()
+	if err != nil {
+		u.emit(UpdateProgress{
+			Stage: StageFailed,
+			Error: err,
+		})
+		return err
+	}
+	defer lock.Release()
+
 	// Download binary to temporary file
-	tmpFile, err := u.download()
+	tmpFile, err := u.download(ctx)
 	if err != nil {
-		u.progress(UpdateProgress{
-			Stage: "failed",
+		u.emit(UpdateProgress{
+			Stage: StageFailed,
 			Error: err,
 		})
 		return fmt.Errorf("download failed: %w", err)
 	}
 	defer os.Remove(tmpFile)
 
-	// Verify checksum if available
-	if u.config.ChecksumURL != "" {
-		if err := u.verifyChecksum(tmpFile); err != nil {
-			u.progress(UpdateProgress{
-				Stage: "failed",
-				Error: err,
-			})
-			return fmt.Errorf("checksum verification failed: %w", err)
-		}
+	// Verify checksum according to policy
+	if err := u.applyChecksumPolicy(ctx, tmpFile); err != nil {
+		u.emit(UpdateProgress{
+			Stage: StageFailed,
+			Error: err,
+		})
+		return fmt.Errorf("checksum verification failed: %w", err)
+	}
+
+	// Verify the detached signature before anything touches the binary
+	if err := u.verifySignature(ctx, tmpFile); err != nil {
+		u.emit(UpdateProgress{
+			Stage: StageFailed,
+			Error: err,
+		})
+		return fmt.Errorf("signature verification failed: %w", err)
+	}
 
-		u.progress(UpdateProgress{
-			Stage: "verifying",
+	// Unpack archives; checksums and signatures cover the asset as published
+	binaryPath, err := u.extractBinary(tmpFile)
+	if err != nil {
+		u.emit(UpdateProgress{
+			Stage: StageFailed,
+			Error: err,
 		})
+		return fmt.Errorf("failed to extract update: %w", err)
+	}
+	if binaryPath != tmpFile {
+		defer os.Remove(binaryPath)
+	}
+
+	// Catch mislabeled assets before the installed binary is touched
+	if err := u.validateDownload(binaryPath); err != nil {
+		u.emit(UpdateProgress{
+			Stage: StageFailed,
+			Error: err,
+		})
+		return fmt.Errorf("downloaded binary rejected: %w", err)
+	}
+
+	// Last chance to cancel before the binary is touched
+	if err := ctx.Err(); err != nil {
+		u.emit(UpdateProgress{
+			Stage: StageFailed,
+			Error: err,
+		})
+		return fmt.Errorf("update cancelled: %w", err)
 	}
 
 	// Apply the update using selfupdate
-	u.progress(UpdateProgress{
-		Stage: "replacing",
+	u.emit(UpdateProgress{
+		Stage: StageReplacing,
 	})
 
-	if err := u.replace(tmpFile); err != nil {
-		u.progress(UpdateProgress{
-			Stage: "failed",
+	if err := u.replace(binaryPath); err != nil {
+		u.emit(UpdateProgress{
+			Stage: StageFailed,
 			Error: err,
 		})
 		return fmt.Errorf("failed to apply update: %w", err)
 	}
 
-	u.progress(UpdateProgress{
-		Stage: "complete",
+	u.emit(UpdateProgress{
+		Stage: StageComplete,
 	})
 
 	return nil
 }
 
-// download downloads the binary from the configured URL to a temporary file.
-func (u *Updater) download() (string, error) {
-	u.progress(UpdateProgress{
-		Stage: "downloading",
+// lock takes the update lock in the state directory, if one is configured.
+// Concurrent runs would share partial downloads and race to replace the
+// target. A nil lock is returned without a state directory.
+func (u *Updater) lock() (*Lock, error) {
+	if u.config.StateDir == "" {
+		return nil, nil
+	}
+	return AcquireLock(u.config.StateDir)
+}
+
+// applyChecksumPolicy verifies the checksum of the downloaded file and
+// decides, based on the configured policy, whether a failure aborts the update.
+func (u *Updater) applyChecksumPolicy(ctx context.Context, filePath string) error {
+	policy := u.config.ChecksumPolicy
+	if policy == "" {
+		policy = ChecksumRequire
+	}
+
+	if policy == ChecksumSkip {
+		return nil
+	}
+
+	u.emit(UpdateProgress{
+		Stage: StageVerifying,
 	})
 
-	// Create temporary file
-	tmpFile, err := os.CreateTemp("", "core-update-*")
+	err := u.verifyChecksum(ctx, filePath)
+	if err == nil {
+		u.emit(UpdateProgress{
+			Stage: StageVerified,
+		})
+		return nil
+	}
+
+	// A mismatch is evidence of corruption or tampering, never just a warning
+	if policy == ChecksumWarn && !errors.Is(err, ErrChecksumMismatch) {
+		u.emit(UpdateProgress{
+			Stage: StageWarning,
+			Error: err,
+		})
+		return nil
+	}
+
+	return err
+}
+
+// verifyChecksum verifies the SHA256 checksum of the downloaded file against
+// the entry for the release asset in the checksum file.
+func (u *Updater) verifyChecksum(ctx context.Context, filePath string) error {
+	expectedHash, err := u.expectedChecksum(ctx)
 	if err != nil {
-		return "", fmt.Errorf("failed to create temporary file: %w", err)
+		return err
 	}
-	tmpPath := tmpFile.Name()
-	tmpFile.Close()
 
-	// Download binary
-	resp, err := u.client.Get(u.config.DownloadURL)
+	// Calculate actual hash
+	actualHash, err := fileSHA256(filePath)
 	if err != nil {
-		os.Remove(tmpPath)
-		return "", fmt.Errorf("failed to download: %w", err)
+		return err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		os.Remove(tmpPath)
-		return "", fmt.Errorf("download returned status %d", resp.StatusCode)
+	if actualHash != expectedHash {
+		return fmt.Errorf("%w: expected %s, got %s", ErrChecksumMismatch, expectedHash, actualHash)
 	}
 
-	// Stream to file and report progress
-	out, err := os.Create(tmpPath)
-	if err != nil {
-		os.Remove(tmpPath)
-		return "", fmt.Errorf("failed to create output file: %w", err)
-	}
-	defer out.Close()
-
-	totalSize := resp.ContentLength
-	var downloaded int64
-
-	// Create progress reader
-	reader := &progressReader{
-		reader: resp.Body,
-		total:  totalSize,
-		update: func(current int64) {
-			downloaded = current
-			percent := int((downloaded * 100) / totalSize)
-			u.progress(UpdateProgress{
-				Stage:      "downloading",
-				Percent:    percent,
-				BytesTotal: totalSize,
-				BytesDone:  downloaded,
-			})
-		},
+	return nil
+}
+
+// expectedChecksum returns the published SHA256 of the asset, taken from
+// the release metadata or looked up in the checksum file.
+func (u *Updater) expectedChecksum(ctx context.Context) (string, error) {
+	if expected := strings.ToLower(strings.TrimSpace(u.config.SHA256)); expected != "" {
+		return expected, nil
 	}
 
-	if _, err := io.Copy(out, reader); err != nil {
-		os.Remove(tmpPath)
-		return "", fmt.Errorf("failed to write file: %w", err)
+	if u.config.ChecksumURL == "" {
+		return "", fmt.Errorf("%w: release has no checksum file", ErrChecksumUnavailable)
 	}
 
-	// Make file executable
-	if err := os.Chmod(tmpPath, 0755); err != nil {
-		os.Remove(tmpPath)
-		return "", fmt.Errorf("failed to make file executable: %w", err)
+	// Parse checksum file (sha256sum format: "hash  filename")
+	body, err := u.fetch(ctx, u.config.ChecksumURL)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrChecksumUnavailable, err)
 	}
 
-	return tmpPath, nil
+	name := u.assetName()
+	expectedHash := u.parseChecksum(string(body), name)
+	if expectedHash == "" {
+		return "", fmt.Errorf("%w: %s", ErrChecksumNotFound, name)
+	}
+
+	return expectedHash, nil
 }
 
-// verifyChecksum verifies the SHA256 checksum of the downloaded file.
-func (u *Updater) verifyChecksum(filePath string) error {
-	if u.config.ChecksumURL == "" {
-		return nil // No checksum to verify
+// fetch downloads a small release file (checksums, signatures) into memory.
+// Unlike the binary, these are never fetched from mirrors, which could
+// otherwise serve a tampered binary together with a matching checksum.
+func (u *Updater) fetch(ctx context.Context, rawURL string) ([]byte, error) {
+	req, err := u.newRequest(ctx, rawURL)
+	if err != nil {
+		return nil, err
 	}
 
-	// Download checksum file
-	resp, err := u.client.Get(u.config.ChecksumURL)
+	resp, err := u.client.Do(req)
 	if err != nil {
-		// Warn but don't fail if checksum fetch fails
-		fmt.Printf("Warning: failed to download checksum: %v\n", err)
-		return nil
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		// Warn but don't fail if checksum not found
-		fmt.Printf("Warning: checksum file returned status %d\n", resp.StatusCode)
-		return nil
+		return nil, fmt.Errorf("%s returned status %d", rawURL, resp.StatusCode)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// newRequest creates the GET request for a release file. With a GitHub
+// token, files that have an API URL are requested from the API instead,
+// which serves the raw asset of private repositories to authenticated
+// requests. GitLab and Gitea files carry the source token instead. Mirrors
+// are always requested anonymously.
+func (u *Updater) newRequest(ctx context.Context, rawURL string) (*http.Request, error) {
+	token := strings.TrimSpace(u.config.GitHubToken)
+	apiURL := u.apiURL(rawURL)
+	if token == "" || apiURL == "" {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		if u.isReleaseFile(rawURL) {
+			u.setSourceAuth(req)
+		}
+		return req, nil
 	}
 
-	// Parse checksum file (assuming sha256sum format: "hash  filename")
-	body, err := io.ReadAll(resp.Body)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
 	if err != nil {
-		fmt.Printf("Warning: failed to read checksum file: %v\n", err)
-		return nil
+		return nil, err
 	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/octet-stream")
+	return req, nil
+}
 
-	expectedHash := u.parseChecksum(string(body), filepath.Base(u.config.TargetPath))
-	if expectedHash == "" {
-		// Couldn't find matching checksum, warn but allow to proceed
-		fmt.Println("Warning: could not find matching checksum in file")
-		return nil
+// isReleaseFile reports whether rawURL is one of the release files served
+// by the source, as opposed to a mirror.
+func (u *Updater) isReleaseFile(rawURL string) bool {
+	switch rawURL {
+	case "":
+		return false
+	case u.config.DownloadURL, u.config.ChecksumURL, u.config.SignatureURL, u.config.ChecksumSignatureURL:
+		return true
 	}
+	return false
+}
 
-	// Calculate actual hash
+// setSourceAuth authenticates req with the GitLab or Gitea source token,
+// in the header each host expects, when req goes to the source's host.
+func (u *Updater) setSourceAuth(req *http.Request) {
+	token := strings.TrimSpace(u.config.SourceToken)
+	if token == "" {
+		return
+	}
+
+	sourceURL := u.config.SourceURL
+	if sourceURL == "" && u.config.SourceType == SourceGitLab {
+		sourceURL = defaultGitLabAPIBaseURL
+	}
+	source, err := url.Parse(sourceURL)
+	if err != nil || source.Host == "" || !strings.EqualFold(source.Host, req.URL.Host) {
+		return
+	}
+
+	switch u.config.SourceType {
+	case SourceGitLab:
+		req.Header.Set("PRIVATE-TOKEN", token)
+	case SourceGitea:
+		req.Header.Set("Authorization", "token "+token)
+	}
+}
+
+// apiURL returns the API URL of the release file served at rawURL.
+func (u *Updater) apiURL(rawURL string) string {
+	switch rawURL {
+	case "":
+		return ""
+	case u.config.DownloadURL:
+		return u.config.AssetAPIURL
+	case u.config.ChecksumURL:
+		return u.config.ChecksumAPIURL
+	case u.config.SignatureURL:
+		return u.config.SignatureAPIURL
+	case u.config.ChecksumSignatureURL:
+		return u.config.ChecksumSignatureAPIURL
+	}
+	return ""
+}
+
+// assetName returns the release asset name of the download, as listed in
+// the checksum file.
+func (u *Updater) assetName() string {
+	if u.config.AssetName != "" {
+		return u.config.AssetName
+	}
+	if parsed, err := url.Parse(u.config.DownloadURL); err == nil && parsed.Path != "" {
+		return path.Base(parsed.Path)
+	}
+	return path.Base(u.config.DownloadURL)
+}
+
+// fileSHA256 returns the hex-encoded SHA256 hash of a file.
+func fileSHA256(filePath string) (string, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
-		return fmt.Errorf("failed to open file for hashing: %w", err)
+		return "", fmt.Errorf("failed to open file for hashing: %w", err)
 	}
 	defer file.Close()
 
 	hash := sha256.New()
 	if _, err := io.Copy(hash, file); err != nil {
-		return fmt.Errorf("failed to hash file: %w", err)
-	}
-
-	actualHash := fmt.Sprintf("%x", hash.Sum(nil))
-
-	if actualHash != expectedHash {
-		return fmt.Errorf("checksum mismatch: expected %s, got %s", expectedHash, actualHash)
+		return "", fmt.Errorf("failed to hash file: %w", err)
 	}
 
-	return nil
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
 }
 
 // parseChecksum extracts the hash for a given filename from a checksum file.
@@ -248,31 +449,60 @@ func (u *Updater) replace(newBinaryPath string) error {
 	}
 	defer newBinary.Close()
 
-	// Apply the update using minio/selfupdate which handles atomic replacement
-	err = selfupdate.Apply(newBinary, selfupdate.Options{
+	opts := selfupdate.Options{
 		TargetPath: u.config.TargetPath,
-	})
+	}
+
+	// Keep the replaced binary next to the target so it can be retained, or
+	// restored if the new one fails its self-test
+	selfTest := u.config.ExpectedVersion != ""
+	var previous BackupEntry
+	if u.config.StateDir != "" || selfTest {
+		opts.OldSavePath = oldSavePath(u.config.TargetPath)
+		previous = BackupEntry{
+			Version:     u.config.CurrentVersion,
+			Commit:      u.config.CurrentCommit,
+			InstalledAt: modTime(u.config.TargetPath),
+		}
+	}
+
+	// Apply the update using minio/selfupdate which handles atomic replacement
+	err = selfupdate.Apply(newBinary, opts)
 
 	if err != nil {
 		return fmt.Errorf("failed to apply update: %w", err)
 	}
 
-	return nil
-}
+	if selfTest {
+		u.emit(UpdateProgress{
+			Stage: StageVerifyingInstall,
+		})
 
-// progressReader wraps a reader and reports progress.
-type progressReader struct {
-	reader io.Reader
-	total  int64
-	read   int64
-	update func(int64)
-}
+		if err := u.selfTest(); err != nil {
+			if rerr := u.rollbackInstall(opts.OldSavePath); rerr != nil {
+				return fmt.Errorf("%w (rollback: %v)", err, rerr)
+			}
+			return fmt.Errorf("%w; previous version restored", err)
+		}
+
+		if u.config.StateDir == "" {
+			os.Remove(opts.OldSavePath)
+			return nil
+		}
+	}
 
-func (pr *progressReader) Read(p []byte) (int, error) {
-	n, err := pr.reader.Read(p)
-	if n > 0 {
-		pr.read += int64(n)
-		pr.update(pr.read)
+	if u.config.StateDir != "" {
+		// The update itself succeeded; a failed backup is only worth a warning
+		history := NewHistory(u.config.StateDir, u.config.KeepBackups)
+		if err := history.Retain(opts.OldSavePath, previous); err != nil {
+			u.emit(UpdateProgress{
+				Stage: StageWarning,
+				Error: fmt.Errorf("previous binary not retained for rollback: %w", err),
+			})
+		} else if entry, err := history.Find(""); err == nil {
+			u.backupPath = entry.Path
+		}
 	}
-	return n, err
+
+	return nil
 }
@@ -1,12 +1,16 @@
 package update
 
 import (
+	"context"
 	"crypto/sha256"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestUpdater_ParseChecksum(t *testing.T) {
@@ -91,7 +95,7 @@ func TestUpdater_MockDownload(t *testing.T) {
 	})
 
 	// Test download
-	tmpFile, err := updater.download()
+	tmpFile, err := updater.download(context.Background())
 	if err != nil {
 		t.Fatalf("download() failed: %v", err)
 	}
@@ -143,11 +147,12 @@ func TestUpdater_VerifyChecksum(t *testing.T) {
 
 	updater := NewUpdater(UpdaterConfig{
 		ChecksumURL: server.URL,
-		TargetPath:  "test-binary",
+		TargetPath:  "/usr/local/bin/core",
+		AssetName:   "test-binary",
 	})
 
 	// This should succeed with matching checksum
-	err := updater.verifyChecksum(testFile)
+	err := updater.verifyChecksum(context.Background(), testFile)
 	if err != nil {
 		t.Errorf("verifyChecksum() failed: %v", err)
 	}
@@ -167,17 +172,108 @@ func TestUpdater_VerifyChecksum_Mismatch(t *testing.T) {
 
 	updater := NewUpdater(UpdaterConfig{
 		ChecksumURL: server.URL,
-		TargetPath:  "test-binary",
+		TargetPath:  "/usr/local/bin/core",
+		AssetName:   "test-binary",
 	})
 
 	// This should fail with mismatched checksum
-	err := updater.verifyChecksum(testFile)
+	err := updater.verifyChecksum(context.Background(), testFile)
 	if err == nil {
 		t.Error("verifyChecksum() should fail with mismatched hash")
 	}
 	if !contains(err.Error(), "mismatch") {
 		t.Errorf("Expected 'mismatch' in error, got: %v", err)
 	}
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Expected ErrChecksumMismatch, got: %v", err)
+	}
+}
+
+func TestUpdater_VerifyChecksum_UsesAssetName(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := tmpDir + "/test-file"
+	testContent := []byte("asset content")
+	os.WriteFile(testFile, testContent, 0644)
+
+	// Only the release asset is listed, not the local binary name
+	checksumContent := fmt.Sprintf("%x  core-linux-amd64\n", sha256.Sum256(testContent))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(checksumContent))
+	}))
+	defer server.Close()
+
+	updater := NewUpdater(UpdaterConfig{
+		DownloadURL: "https://example.com/releases/download/v1.2.0/core-linux-amd64",
+		ChecksumURL: server.URL,
+		TargetPath:  tmpDir + "/core",
+	})
+
+	if err := updater.verifyChecksum(context.Background(), testFile); err != nil {
+		t.Errorf("verifyChecksum() should match by asset name, got: %v", err)
+	}
+
+	updater.config.AssetName = "core-darwin-arm64"
+	if err := updater.verifyChecksum(context.Background(), testFile); !errors.Is(err, ErrChecksumNotFound) {
+		t.Errorf("Expected ErrChecksumNotFound, got: %v", err)
+	}
+}
+
+func TestUpdater_ChecksumPolicy(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := tmpDir + "/test-file"
+	os.WriteFile(testFile, []byte("test content"), 0644)
+
+	missing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer missing.Close()
+
+	mismatch := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("0000000000000000000000000000000000000000000000000000000000000000  core-linux-amd64\n"))
+	}))
+	defer mismatch.Close()
+
+	tests := []struct {
+		name        string
+		policy      ChecksumPolicy
+		checksumURL string
+		wantErr     error
+		wantWarning bool
+	}{
+		{"require fails when unavailable", ChecksumRequire, missing.URL, ErrChecksumUnavailable, false},
+		{"default requires checksum", "", "", ErrChecksumUnavailable, false},
+		{"warn reports unavailable", ChecksumWarn, missing.URL, nil, true},
+		{"warn still fails on mismatch", ChecksumWarn, mismatch.URL, ErrChecksumMismatch, false},
+		{"skip ignores mismatch", ChecksumSkip, mismatch.URL, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updater := NewUpdater(UpdaterConfig{
+				ChecksumURL:    tt.checksumURL,
+				AssetName:      "core-linux-amd64",
+				ChecksumPolicy: tt.policy,
+			})
+
+			warned := false
+			updater.SetProgressCallback(func(up UpdateProgress) {
+				if up.Stage == "warning" {
+					warned = true
+				}
+			})
+
+			err := updater.applyChecksumPolicy(context.Background(), testFile)
+			if tt.wantErr == nil && err != nil {
+				t.Errorf("applyChecksumPolicy() unexpected error: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("applyChecksumPolicy() error = %v, want %v", err, tt.wantErr)
+			}
+			if warned != tt.wantWarning {
+				t.Errorf("warning reported = %v, want %v", warned, tt.wantWarning)
+			}
+		})
+	}
 }
 
 func TestUpdater_DownloadProgress(t *testing.T) {
@@ -205,7 +301,7 @@ func TestUpdater_DownloadProgress(t *testing.T) {
 		progressReports = append(progressReports, up)
 	})
 
-	tmpFile, err := updater.download()
+	tmpFile, err := updater.download(context.Background())
 	if err != nil {
 		t.Fatalf("download() failed: %v", err)
 	}
@@ -242,7 +338,7 @@ func TestUpdater_SetProgressCallback(t *testing.T) {
 		Stage:   "test",
 		Percent: 50,
 	}
-	updater.progress(testProgress)
+	updater.emit(testProgress)
 
 	if !callbackCalled {
 		t.Error("Progress callback was not called")
@@ -266,7 +362,7 @@ func TestUpdater_DownloadFailure(t *testing.T) {
 		TargetPath:  tmpDir + "/test-binary",
 	})
 
-	_, err := updater.download()
+	_, err := updater.download(context.Background())
 	if err == nil {
 		t.Error("download() should fail with 404 status")
 	}
@@ -304,7 +400,7 @@ func TestUpdater_Config_Validation(t *testing.T) {
 }
 
 func TestUpdater_Progress_Stages(t *testing.T) {
-	stages := []string{
+	stages := []Stage{
 		"downloading",
 		"verifying",
 		"replacing",
@@ -339,3 +435,177 @@ func contains(s, substr string) bool {
 	}
 	return false
 }
+
+func TestUpdater_PrivateAssetDownload(t *testing.T) {
+	content := []byte("private binary")
+	checksums := fmt.Sprintf("%x  core-linux-amd64\n", sha256.Sum256(content))
+
+	// The storage host serving redirected downloads must never see the token
+	var leaked []string
+	storage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "" {
+			leaked = append(leaked, r.URL.Path+": "+auth)
+		}
+		switch r.URL.Path {
+		case "/blob/1":
+			w.Write(content)
+		case "/blob/2":
+			w.Write([]byte(checksums))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer storage.Close()
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, ok := strings.CutPrefix(r.URL.Path, "/repos/test/private/releases/assets/")
+		switch {
+		case !ok:
+			// Browser download URLs of private repositories are not found
+			http.NotFound(w, r)
+		case r.Header.Get("Authorization") != "Bearer secret":
+			http.NotFound(w, r)
+		case r.Header.Get("Accept") != "application/octet-stream":
+			w.Write([]byte(`{"id":` + id + `}`))
+		default:
+			http.Redirect(w, r, storage.URL+"/blob/"+id, http.StatusFound)
+		}
+	}))
+	defer api.Close()
+
+	updater := NewUpdater(UpdaterConfig{
+		DownloadURL:    api.URL + "/test/private/releases/download/v1.1.0/core-linux-amd64",
+		ChecksumURL:    api.URL + "/test/private/releases/download/v1.1.0/checksums.txt",
+		AssetAPIURL:    api.URL + "/repos/test/private/releases/assets/1",
+		ChecksumAPIURL: api.URL + "/repos/test/private/releases/assets/2",
+		GitHubToken:    "secret",
+		TargetPath:     t.TempDir() + "/core",
+		RetryBaseDelay: time.Millisecond,
+	})
+
+	tmpFile, err := updater.download(context.Background())
+	if err != nil {
+		t.Fatalf("download() failed: %v", err)
+	}
+	defer os.Remove(tmpFile)
+
+	data, err := os.ReadFile(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to read download: %v", err)
+	}
+	if string(data) != string(content) {
+		t.Errorf("Downloaded %q, want %q", data, content)
+	}
+
+	if err := updater.verifyChecksum(context.Background(), tmpFile); err != nil {
+		t.Errorf("verifyChecksum() failed: %v", err)
+	}
+
+	if len(leaked) > 0 {
+		t.Errorf("Token sent to the redirect host: %v", leaked)
+	}
+}
+
+func TestUpdater_SourceTokenDownload(t *testing.T) {
+	tests := []struct {
+		source       SourceType
+		header, want string
+	}{
+		{SourceGitLab, "PRIVATE-TOKEN", "secret"},
+		{SourceGitea, "Authorization", "token secret"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.source), func(t *testing.T) {
+			content := []byte("private binary")
+			checksums := fmt.Sprintf("%x  core-linux-amd64\n", sha256.Sum256(content))
+
+			// Mirrors and redirect targets must never see the token
+			var leaked []string
+			record := func(r *http.Request) {
+				if r.Header.Get(tt.header) != "" {
+					leaked = append(leaked, r.Host+r.URL.Path)
+				}
+			}
+			storage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				record(r)
+				w.Write(content)
+			}))
+			defer storage.Close()
+			mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				record(r)
+				http.NotFound(w, r)
+			}))
+			defer mirror.Close()
+
+			source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get(tt.header) != tt.want {
+					http.NotFound(w, r)
+					return
+				}
+				switch r.URL.Path {
+				case "/forks/core/releases/download/v1.1.0/core-linux-amd64":
+					http.Redirect(w, r, storage.URL+"/blob/1", http.StatusFound)
+				case "/forks/core/releases/download/v1.1.0/checksums.txt":
+					w.Write([]byte(checksums))
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer source.Close()
+
+			updater := NewUpdater(UpdaterConfig{
+				DownloadURL:    source.URL + "/forks/core/releases/download/v1.1.0/core-linux-amd64",
+				DownloadURLs:   []string{mirror.URL + "/v1.1.0/core-linux-amd64"},
+				ChecksumURL:    source.URL + "/forks/core/releases/download/v1.1.0/checksums.txt",
+				SourceType:     tt.source,
+				SourceURL:      source.URL + "/api",
+				SourceToken:    "secret",
+				TargetPath:     t.TempDir() + "/core",
+				RetryBaseDelay: time.Millisecond,
+			})
+
+			tmpFile, err := updater.download(context.Background())
+			if err != nil {
+				t.Fatalf("download() failed: %v", err)
+			}
+			defer os.Remove(tmpFile)
+
+			if err := updater.verifyChecksum(context.Background(), tmpFile); err != nil {
+				t.Errorf("verifyChecksum() failed: %v", err)
+			}
+			if len(leaked) > 0 {
+				t.Errorf("Token sent to another host: %v", leaked)
+			}
+		})
+	}
+}
+
+func TestCheckRedirect_StripsAuthorization(t *testing.T) {
+	via := []*http.Request{httptest.NewRequest(http.MethodGet, "https://api.github.com/repos/o/r/releases/assets/1", nil)}
+
+	tests := []struct {
+		target   string
+		keepAuth bool
+	}{
+		{"https://api.github.com/other", true},
+		{"https://objects.githubusercontent.com/blob", false},
+		{"https://sub.api.github.com/blob", false},
+		{"https://api.github.com:8443/blob", false},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		req.Header.Set("PRIVATE-TOKEN", "secret")
+		if err := checkRedirect(req, via); err != nil {
+			t.Fatalf("checkRedirect(%s) failed: %v", tt.target, err)
+		}
+		if got := req.Header.Get("Authorization") != ""; got != tt.keepAuth {
+			t.Errorf("checkRedirect(%s) kept Authorization = %v, want %v", tt.target, got, tt.keepAuth)
+		}
+		if got := req.Header.Get("PRIVATE-TOKEN") != ""; got != tt.keepAuth {
+			t.Errorf("checkRedirect(%s) kept PRIVATE-TOKEN = %v, want %v", tt.target, got, tt.keepAuth)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package update
+
+import (
+	"debug/buildinfo"
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// ErrInvalidBinary is returned when a downloaded binary is not a build of
+// CORE CLI for this platform and release.
+var ErrInvalidBinary = errors.New("invalid binary")
+
+// binaryFormat is the executable format and architecture read from a
+// file header. Universal Mach-O files list several architectures.
+type binaryFormat struct {
+	goos   string // "linux" for any ELF, "darwin" or "windows"
+	arches []string
+	// section holds the slice matching the requested architecture of a
+	// universal binary, which build info must be read from.
+	section io.ReaderAt
+}
+
+// validateBinary checks that the file at path is an executable for goos and
+// goarch built from modulePath, and, when expectedVersion is set, that the
+// version ldflag matches it.
+func validateBinary(path, goos, goarch, modulePath, expectedVersion string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open binary: %w", err)
+	}
+	defer f.Close()
+
+	format, err := readBinaryFormat(f, goarch)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidBinary, err)
+	}
+
+	if format.goos != executableFamily(goos) {
+		return fmt.Errorf("%w: built for %s, this system runs %s", ErrInvalidBinary, format.goos, goos)
+	}
+	if !slices.Contains(format.arches, goarch) {
+		return fmt.Errorf("%w: built for %s, this system is %s", ErrInvalidBinary, strings.Join(format.arches, "+"), goarch)
+	}
+
+	info, err := buildinfo.Read(format.section)
+	if err != nil {
+		return fmt.Errorf("%w: no Go build info: %v", ErrInvalidBinary, err)
+	}
+
+	if info.Main.Path != modulePath {
+		return fmt.Errorf("%w: built from module %q, expected %s", ErrInvalidBinary, info.Main.Path, modulePath)
+	}
+
+	if expectedVersion == "" {
+		return nil
+	}
+
+	ldflags := ""
+	for _, setting := range info.Settings {
+		if setting.Key == "-ldflags" {
+			ldflags = setting.Value
+		}
+	}
+	// Builds with -trimpath do not record ldflags; the post-install
+	// self-test still checks the version
+	if ldflags == "" {
+		return nil
+	}
+
+	versionVar := modulePath + "/internal/version.Version"
+	injected, ok := ldflagValue(ldflags, versionVar)
+	if !ok {
+		return fmt.Errorf("%w: %s not set at build time", ErrInvalidBinary, versionVar)
+	}
+	if NormalizeVersion(injected) != NormalizeVersion(expectedVersion) {
+		return fmt.Errorf("%w: built as version %q, expected %s", ErrInvalidBinary, injected, expectedVersion)
+	}
+
+	return nil
+}
+
+// readBinaryFormat identifies an ELF, Mach-O (thin or universal) or PE file.
+func readBinaryFormat(f *os.File, goarch string) (binaryFormat, error) {
+	var magic [4]byte
+	if _, err := f.ReadAt(magic[:], 0); err != nil {
+		return binaryFormat{}, fmt.Errorf("file too short to be an executable")
+	}
+
+	switch {
+	case string(magic[:]) == elf.ELFMAG:
+		file, err := elf.NewFile(f)
+		if err != nil {
+			return binaryFormat{}, err
+		}
+		defer file.Close()
+		return binaryFormat{goos: "linux", arches: []string{elfArch(file)}, section: f}, nil
+
+	case magic[0] == 'M' && magic[1] == 'Z':
+		file, err := pe.NewFile(f)
+		if err != nil {
+			return binaryFormat{}, err
+		}
+		defer file.Close()
+		return binaryFormat{goos: "windows", arches: []string{peArch(file.Machine)}, section: f}, nil
+
+	case binary.BigEndian.Uint32(magic[:]) == macho.MagicFat:
+		fat, err := macho.NewFatFile(f)
+		if err != nil {
+			return binaryFormat{}, err
+		}
+		defer fat.Close()
+
+		format := binaryFormat{goos: "darwin"}
+		for _, arch := range fat.Arches {
+			name := machoArch(arch.Cpu)
+			format.arches = append(format.arches, name)
+			if name == goarch {
+				format.section = io.NewSectionReader(f, int64(arch.Offset), int64(arch.Size))
+			}
+		}
+		if format.section == nil {
+			format.section = f
+		}
+		return format, nil
+
+	default:
+		file, err := macho.NewFile(f)
+		if err != nil {
+			return binaryFormat{}, fmt.Errorf("not an ELF, Mach-O or PE executable")
+		}
+		defer file.Close()
+		return binaryFormat{goos: "darwin", arches: []string{machoArch(file.Cpu)}, section: f}, nil
+	}
+}
+
+// executableFamily maps GOOS to the name readBinaryFormat reports for its
+// executable format.
+func executableFamily(goos string) string {
+	switch goos {
+	case "darwin", "ios":
+		return "darwin"
+	case "windows":
+		return "windows"
+	default:
+		return "linux"
+	}
+}
+
+func elfArch(f *elf.File) string {
+	switch f.Machine {
+	case elf.EM_X86_64:
+		return "amd64"
+	case elf.EM_386:
+		return "386"
+	case elf.EM_AARCH64:
+		return "arm64"
+	case elf.EM_ARM:
+		return "arm"
+	case elf.EM_RISCV:
+		return "riscv64"
+	case elf.EM_LOONGARCH:
+		return "loong64"
+	case elf.EM_S390:
+		return "s390x"
+	case elf.EM_PPC64:
+		if f.ByteOrder == binary.LittleEndian {
+			return "ppc64le"
+		}
+		return "ppc64"
+	case elf.EM_MIPS:
+		little := f.ByteOrder == binary.LittleEndian
+		switch {
+		case f.Class == elf.ELFCLASS64 && little:
+			return "mips64le"
+		case f.Class == elf.ELFCLASS64:
+			return "mips64"
+		case little:
+			return "mipsle"
+		}
+		return "mips"
+	}
+	return f.Machine.String()
+}
+
+func machoArch(cpu macho.Cpu) string {
+	switch cpu {
+	case macho.CpuAmd64:
+		return "amd64"
+	case macho.CpuArm64:
+		return "arm64"
+	case macho.Cpu386:
+		return "386"
+	case macho.CpuArm:
+		return "arm"
+	}
+	return cpu.String()
+}
+
+func peArch(machine uint16) string {
+	switch machine {
+	case pe.IMAGE_FILE_MACHINE_AMD64:
+		return "amd64"
+	case pe.IMAGE_FILE_MACHINE_I386:
+		return "386"
+	case pe.IMAGE_FILE_MACHINE_ARM64:
+		return "arm64"
+	case pe.IMAGE_FILE_MACHINE_ARMNT:
+		return "arm"
+	}
+	return fmt.Sprintf("machine 0x%x", machine)
+}
+
+// ldflagValue returns the value assigned to name by a -X flag in ldflags,
+// as recorded in the build info (quoting preserved). Like the linker, the
+// last assignment wins.
+func ldflagValue(ldflags, name string) (value string, found bool) {
+	args := splitQuoted(ldflags)
+	for i, arg := range args {
+		var assignment string
+		switch {
+		case (arg == "-X" || arg == "--X") && i+1 < len(args):
+			assignment = args[i+1]
+		case strings.HasPrefix(arg, "-X="):
+			assignment = strings.TrimPrefix(arg, "-X=")
+		case strings.HasPrefix(arg, "--X="):
+			assignment = strings.TrimPrefix(arg, "--X=")
+		default:
+			continue
+		}
+
+		if v, ok := strings.CutPrefix(assignment, name+"="); ok {
+			value, found = v, true
+		}
+	}
+	return value, found
+}
+
+// splitQuoted splits s at spaces outside single or double quotes, the way
+// the go command splits -ldflags.
+func splitQuoted(s string) []string {
+	var (
+		args    []string
+		current strings.Builder
+		quote   rune
+		inArg   bool
+	)
+
+	for _, r := range s {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			current.WriteRune(r)
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+
+	return args
+}
+
+// validateDownload runs validateBinary for the running platform when the
+// updater is configured with a module path.
+func (u *Updater) validateDownload(path string) error {
+	if u.config.ModulePath == "" {
+		return nil
+	}
+	return validateBinary(path, runtime.GOOS, runtime.GOARCH, u.config.ModulePath, u.config.ExpectedVersion)
+}
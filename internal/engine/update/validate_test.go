@@ -0,0 +1,143 @@
+package update
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// The test binary is a real executable for this platform built from the
+// core-cli module, without ldflags.
+const testModulePath = "github.com/Tfc538/core-cli"
+
+func testExecutable(t *testing.T) string {
+	t.Helper()
+	exe, err := os.Executable()
+	if err != nil {
+		t.Skipf("cannot locate test binary: %v", err)
+	}
+	return exe
+}
+
+func TestValidateBinary(t *testing.T) {
+	exe := testExecutable(t)
+
+	otherArch := "arm64"
+	if runtime.GOARCH == "arm64" {
+		otherArch = "amd64"
+	}
+	otherOS := "windows"
+	if runtime.GOOS == "windows" {
+		otherOS = "linux"
+	}
+
+	text := filepath.Join(t.TempDir(), "core")
+	os.WriteFile(text, []byte("#!/bin/sh\necho not a go binary\n"), 0755)
+
+	tests := []struct {
+		name       string
+		path       string
+		goos       string
+		goarch     string
+		modulePath string
+		wantErr    bool
+	}{
+		{"matching binary", exe, runtime.GOOS, runtime.GOARCH, testModulePath, false},
+		{"wrong architecture", exe, runtime.GOOS, otherArch, testModulePath, true},
+		{"wrong operating system", exe, otherOS, runtime.GOARCH, testModulePath, true},
+		{"wrong module", exe, runtime.GOOS, runtime.GOARCH, "github.com/example/other", true},
+		{"not an executable", text, runtime.GOOS, runtime.GOARCH, testModulePath, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateBinary(tt.path, tt.goos, tt.goarch, tt.modulePath, "1.2.0")
+			if tt.wantErr && !errors.Is(err, ErrInvalidBinary) {
+				t.Errorf("Expected ErrInvalidBinary, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Expected binary to validate, got %v", err)
+			}
+		})
+	}
+}
+
+func TestLdflagValue(t *testing.T) {
+	const name = "github.com/Tfc538/core-cli/internal/version.Version"
+
+	tests := []struct {
+		ldflags string
+		want    string
+		found   bool
+	}{
+		{"-X '" + name + "=1.2.0' -X 'github.com/Tfc538/core-cli/internal/version.GitCommit=abc'", "1.2.0", true},
+		{"-s -w -X " + name + "=v1.2.0", "v1.2.0", true},
+		{`-X="` + name + `=1.2.0-beta.1"`, "1.2.0-beta.1", true},
+		{"-X " + name + "=1.0.0 -X " + name + "=1.1.0", "1.1.0", true},
+		{"-X '" + name + "='", "", true},
+		{"-s -w", "", false},
+		{"-X " + name + "Suffix=1.0.0", "", false},
+	}
+
+	for _, tt := range tests {
+		got, found := ldflagValue(tt.ldflags, name)
+		if got != tt.want || found != tt.found {
+			t.Errorf("ldflagValue(%q) = %q, %v; want %q, %v", tt.ldflags, got, found, tt.want, tt.found)
+		}
+	}
+}
+
+func TestUpdater_Apply_RejectsInvalidBinary(t *testing.T) {
+	exe := testExecutable(t)
+	content, err := os.ReadFile(exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(content)
+	}))
+	defer server.Close()
+
+	for _, tt := range []struct {
+		modulePath string
+		wantErr    bool
+	}{
+		{testModulePath, false},
+		{"github.com/example/other", true},
+	} {
+		target := filepath.Join(t.TempDir(), "core")
+		os.WriteFile(target, []byte("old binary"), 0755)
+
+		updater := NewUpdater(UpdaterConfig{
+			DownloadURL:    server.URL,
+			TargetPath:     target,
+			ChecksumPolicy: ChecksumSkip,
+			ModulePath:     tt.modulePath,
+		})
+
+		err := updater.Apply()
+		installed, _ := os.ReadFile(target)
+
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidBinary) {
+				t.Errorf("Expected ErrInvalidBinary for module %s, got %v", tt.modulePath, err)
+			}
+			if string(installed) != "old binary" {
+				t.Error("Target binary must be untouched when validation fails")
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Apply() failed: %v", err)
+		}
+		if len(installed) != len(content) {
+			t.Error("Expected the validated binary to be installed")
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package network
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ProbeResult describes one diagnostic request.
+type ProbeResult struct {
+	URL        string        `json:"url"`
+	Proxy      string        `json:"proxy,omitempty"`
+	Status     int           `json:"status,omitempty"`
+	TLSVersion string        `json:"tls_version,omitempty"`
+	DNS        time.Duration `json:"dns_ns,omitempty"`
+	Connect    time.Duration `json:"connect_ns,omitempty"`
+	TLS        time.Duration `json:"tls_ns,omitempty"`
+	Total      time.Duration `json:"total_ns"`
+	Error      string        `json:"error,omitempty"`
+	// Hint suggests a configuration change when the request failed.
+	Hint string `json:"hint,omitempty"`
+}
+
+// OK reports whether the endpoint answered. Any HTTP status counts, since
+// the probe checks connectivity rather than the endpoint's behaviour.
+func (r ProbeResult) OK() bool {
+	return r.Error == ""
+}
+
+// Probe sends a GET to rawURL through transport and records the time spent
+// in each connection phase.
+func Probe(ctx context.Context, transport *http.Transport, rawURL string) ProbeResult {
+	result := ProbeResult{URL: rawURL}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	if transport.Proxy != nil {
+		if proxy, err := transport.Proxy(req); err == nil && proxy != nil {
+			result.Proxy = redact(proxy)
+		}
+	}
+
+	var dnsStart, connectStart, tlsStart time.Time
+	trace := &httptrace.ClientTrace{
+		DNSStart:          func(httptrace.DNSStartInfo) { dnsStart = time.Now() },
+		DNSDone:           func(httptrace.DNSDoneInfo) { result.DNS = time.Since(dnsStart) },
+		ConnectStart:      func(string, string) { connectStart = time.Now() },
+		ConnectDone:       func(string, string, error) { result.Connect = time.Since(connectStart) },
+		TLSHandshakeStart: func() { tlsStart = time.Now() },
+		TLSHandshakeDone: func(state tls.ConnectionState, _ error) {
+			result.TLS = time.Since(tlsStart)
+			if state.Version != 0 {
+				result.TLSVersion = tls.VersionName(state.Version)
+			}
+		},
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+
+	// A fresh connection every time, so each phase is measured
+	transport = transport.Clone()
+	transport.DisableKeepAlives = true
+
+	start := time.Now()
+	resp, err := transport.RoundTrip(req)
+	result.Total = time.Since(start)
+	if err != nil {
+		result.Error = err.Error()
+		result.Hint = hint(err)
+		return result
+	}
+	resp.Body.Close()
+
+	result.Status = resp.StatusCode
+	return result
+}
+
+// hint maps common connection failures to the setting that fixes them.
+func hint(err error) string {
+	var (
+		unknownAuthority x509.UnknownAuthorityError
+		hostname         x509.HostnameError
+		dnsErr           *net.DNSError
+	)
+
+	switch {
+	case errors.As(err, &unknownAuthority):
+		return "the server certificate is signed by an unknown authority; set ca_bundle to your organisation's root CA"
+	case errors.As(err, &hostname):
+		return "the server certificate does not match the host; check for a TLS-intercepting proxy"
+	case strings.Contains(err.Error(), "certificate required"), strings.Contains(err.Error(), "bad certificate"):
+		// TLS alerts are not exported as error types
+		return "the server rejected the client certificate; set client_cert and client_key"
+	case errors.As(err, &dnsErr):
+		return "the host name could not be resolved; check DNS or configure a proxy"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "the connection timed out; a proxy may be required"
+	}
+	return ""
+}
+
+// redact hides proxy credentials.
+func redact(u *url.URL) string {
+	if u.User == nil {
+		return u.String()
+	}
+	copied := *u
+	copied.User = url.User("xxxxx")
+	return copied.String()
+}
@@ -0,0 +1,210 @@
+// Package network builds the HTTP transport shared by every outgoing
+// connection of the CLI, so corporate proxies, private CAs and client
+// certificates are configured once.
+package network
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Direct is the proxy value that disables proxying for a host.
+const Direct = "direct"
+
+const (
+	defaultDialTimeout           = 30 * time.Second
+	defaultTLSHandshakeTimeout   = 10 * time.Second
+	defaultResponseHeaderTimeout = 30 * time.Second
+)
+
+// Options configures NewTransport. The zero value behaves like
+// http.DefaultTransport, including proxies from the environment.
+type Options struct {
+	// CABundle is a PEM file of root certificates trusted in addition to
+	// the system pool.
+	CABundle string
+	// ClientCert and ClientKey are PEM files presented for mutual TLS.
+	ClientCert string
+	ClientKey  string
+
+	// Proxy is the proxy URL for all hosts not matched below. When empty,
+	// HTTPS_PROXY, HTTP_PROXY and NO_PROXY from the environment apply.
+	Proxy string
+	// NoProxy lists hosts reached directly. An entry matches the host and
+	// its subdomains; "*" matches every host.
+	NoProxy []string
+	// HostProxies maps hosts, matched like NoProxy, to a proxy URL or
+	// Direct. The longest matching host wins over Proxy and NoProxy.
+	HostProxies map[string]string
+
+	DialTimeout           time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+}
+
+// NewTransport returns an HTTP transport configured by opts.
+func NewTransport(opts Options) (*http.Transport, error) {
+	tlsConfig, err := opts.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	proxy, err := opts.proxyFunc()
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxy
+	transport.TLSClientConfig = tlsConfig
+	transport.DialContext = (&net.Dialer{
+		Timeout:   durationOr(opts.DialTimeout, defaultDialTimeout),
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	transport.TLSHandshakeTimeout = durationOr(opts.TLSHandshakeTimeout, defaultTLSHandshakeTimeout)
+	transport.ResponseHeaderTimeout = durationOr(opts.ResponseHeaderTimeout, defaultResponseHeaderTimeout)
+
+	return transport, nil
+}
+
+// ProxyFor returns the proxy a request to rawURL goes through, or nil for a
+// direct connection.
+func (o Options) ProxyFor(rawURL string) (*url.URL, error) {
+	proxy, err := o.proxyFunc()
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	return proxy(&http.Request{URL: u})
+}
+
+// tlsConfig loads the CA bundle and client certificate.
+func (o Options) tlsConfig() (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if o.CABundle != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+
+		pem, err := os.ReadFile(o.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("CA bundle %s contains no PEM certificates", o.CABundle)
+		}
+		config.RootCAs = pool
+	}
+
+	if o.ClientCert != "" || o.ClientKey != "" {
+		if o.ClientCert == "" || o.ClientKey == "" {
+			return nil, fmt.Errorf("client certificate and key must be configured together")
+		}
+		cert, err := tls.LoadX509KeyPair(o.ClientCert, o.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
+// proxyFunc returns the transport's proxy selection.
+func (o Options) proxyFunc() (func(*http.Request) (*url.URL, error), error) {
+	defaultProxy, err := parseProxy(o.Proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	hostProxies := make(map[string]*url.URL, len(o.HostProxies))
+	for host, value := range o.HostProxies {
+		proxy, err := parseProxy(value)
+		if err != nil {
+			return nil, fmt.Errorf("proxy for %s: %w", host, err)
+		}
+		hostProxies[normalizeHost(host)] = proxy
+	}
+
+	return func(req *http.Request) (*url.URL, error) {
+		host := normalizeHost(req.URL.Hostname())
+
+		best := -1
+		var selected *url.URL
+		for pattern, proxy := range hostProxies {
+			if matchHost(host, pattern) && len(pattern) > best {
+				best, selected = len(pattern), proxy
+			}
+		}
+		if best >= 0 {
+			return selected, nil
+		}
+
+		for _, pattern := range o.NoProxy {
+			if matchHost(host, normalizeHost(pattern)) {
+				return nil, nil
+			}
+		}
+
+		if o.Proxy == "" {
+			return http.ProxyFromEnvironment(req)
+		}
+		return defaultProxy, nil
+	}, nil
+}
+
+// parseProxy parses a proxy URL. Direct and "" yield nil.
+func parseProxy(value string) (*url.URL, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.EqualFold(value, Direct) {
+		return nil, nil
+	}
+
+	// Like the environment variables, accept host:port without a scheme
+	if !strings.Contains(value, "://") {
+		value = "http://" + value
+	}
+
+	proxy, err := url.Parse(value)
+	if err != nil || proxy.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q", value)
+	}
+	switch proxy.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q", proxy.Scheme)
+	}
+	return proxy, nil
+}
+
+func normalizeHost(host string) string {
+	return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(host)), ".")
+}
+
+// matchHost reports whether host is pattern or one of its subdomains.
+func matchHost(host, pattern string) bool {
+	if pattern == "*" {
+		return true
+	}
+	return host == pattern || strings.HasSuffix(host, "."+pattern)
+}
+
+func durationOr(d, fallback time.Duration) time.Duration {
+	if d <= 0 {
+		return fallback
+	}
+	return d
+}
@@ -0,0 +1,182 @@
+package network
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeServerCert writes the certificate and key of a TLS test server as
+// PEM files.
+func writeServerCert(t *testing.T, server *httptest.Server) (certPath, keyPath string) {
+	t.Helper()
+
+	cert := server.TLS.Certificates[0]
+	dir := t.TempDir()
+
+	certPath = filepath.Join(dir, "cert.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]})
+	if err := os.WriteFile(certPath, certPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	keyPath = filepath.Join(dir, "key.pem")
+	key, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: key})
+	if err := os.WriteFile(keyPath, keyPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return certPath, keyPath
+}
+
+func TestNewTransport_CABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	certPath, _ := writeServerCert(t, server)
+
+	// Without the bundle the test CA is unknown
+	transport, err := NewTransport(Options{})
+	if err != nil {
+		t.Fatalf("NewTransport() failed: %v", err)
+	}
+	result := Probe(context.Background(), transport, server.URL)
+	if result.OK() {
+		t.Fatal("Expected an untrusted certificate to fail")
+	}
+	if !strings.Contains(result.Hint, "ca_bundle") {
+		t.Errorf("Expected a ca_bundle hint, got %q", result.Hint)
+	}
+
+	transport, err = NewTransport(Options{CABundle: certPath})
+	if err != nil {
+		t.Fatalf("NewTransport() failed: %v", err)
+	}
+	result = Probe(context.Background(), transport, server.URL)
+	if !result.OK() || result.Status != http.StatusNoContent {
+		t.Fatalf("Expected success with the CA bundle, got %+v", result)
+	}
+	if result.TLSVersion == "" {
+		t.Error("Expected the TLS version to be recorded")
+	}
+}
+
+func TestNewTransport_ClientCertificate(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 {
+			http.Error(w, "no client certificate", http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	// The test server's own key pair doubles as the client certificate
+	certPath, keyPath := writeServerCert(t, server)
+
+	transport, err := NewTransport(Options{CABundle: certPath, ClientCert: certPath, ClientKey: keyPath})
+	if err != nil {
+		t.Fatalf("NewTransport() failed: %v", err)
+	}
+
+	result := Probe(context.Background(), transport, server.URL)
+	if result.Status != http.StatusOK {
+		t.Errorf("Expected the client certificate to be presented, got %+v", result)
+	}
+}
+
+func TestNewTransport_InvalidOptions(t *testing.T) {
+	dir := t.TempDir()
+	notPEM := filepath.Join(dir, "bundle.pem")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{"missing CA bundle", Options{CABundle: filepath.Join(dir, "missing.pem")}},
+		{"CA bundle without certificates", Options{CABundle: notPEM}},
+		{"certificate without key", Options{ClientCert: notPEM}},
+		{"unsupported proxy scheme", Options{Proxy: "ftp://proxy:21"}},
+		{"invalid host proxy", Options{HostProxies: map[string]string{"example.com": "http://"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewTransport(tt.opts); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func TestOptions_ProxyFor(t *testing.T) {
+	opts := Options{
+		Proxy:   "proxy.corp:3128",
+		NoProxy: []string{"corp.internal"},
+		HostProxies: map[string]string{
+			"example.com":        "http://edge.corp:8080",
+			"mirror.example.com": Direct,
+		},
+	}
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://api.github.com/repos", "http://proxy.corp:3128"},
+		{"https://git.corp.internal/api", ""},
+		{"https://corp.internal/", ""},
+		{"https://dl.example.com/core", "http://edge.corp:8080"},
+		{"https://mirror.example.com/core", ""},
+		{"https://notexample.com/", "http://proxy.corp:3128"},
+	}
+
+	for _, tt := range tests {
+		proxy, err := opts.ProxyFor(tt.url)
+		if err != nil {
+			t.Fatalf("ProxyFor(%s) failed: %v", tt.url, err)
+		}
+
+		got := ""
+		if proxy != nil {
+			got = proxy.String()
+		}
+		if got != tt.want {
+			t.Errorf("ProxyFor(%s) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestProbe_UnreachableHost(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	transport, err := NewTransport(Options{})
+	if err != nil {
+		t.Fatalf("NewTransport() failed: %v", err)
+	}
+
+	result := Probe(context.Background(), transport, url)
+	if result.OK() || result.Error == "" {
+		t.Errorf("Expected a closed port to fail, got %+v", result)
+	}
+}
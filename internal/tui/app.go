@@ -1,9 +1,15 @@
 package tui
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"time"
 
+	"github.com/Tfc538/core-cli/internal/config"
 	"github.com/Tfc538/core-cli/internal/engine/update"
+	"github.com/Tfc538/core-cli/internal/network"
 	"github.com/Tfc538/core-cli/internal/version"
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -18,6 +24,8 @@ type Model struct {
 	updateError      string
 	hasCheckedUpdate bool
 	updateInProgress bool
+	// updateLocked is set while another process holds the update lock
+	updateLocked *update.LockedError
 
 	// Update progress
 	updateProgress update.UpdateProgress
@@ -25,12 +33,20 @@ type Model struct {
 	// UI state
 	width  int
 	height int
+
+	// Cancels background work, such as update checks, when the TUI quits
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
-// New creates a new TUI model.
-func New() *Model {
+// New creates a new TUI model. Background work stops when ctx is cancelled
+// or the TUI quits.
+func New(ctx context.Context) *Model {
+	ctx, cancel := context.WithCancel(ctx)
 	return &Model{
 		currentVersion: version.Version,
+		ctx:            ctx,
+		cancel:         cancel,
 	}
 }
 
@@ -46,10 +62,17 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "ctrl+c", "q":
+			if m.cancel != nil {
+				m.cancel()
+			}
 			return m, tea.Quit
 		case "u":
 			// 'u' key: show/trigger update
 			if m.updateInfo != nil && m.updateInfo.UpdateAvailable && !m.updateInProgress {
+				// Never start a second update next to a running one
+				if m.updateLocked = updateLockHolder(); m.updateLocked != nil {
+					break
+				}
 				m.updateInProgress = true
 				// In a full implementation, this would launch the update
 				// For now, we just flag it
@@ -65,12 +88,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.hasCheckedUpdate = true
 		m.updateError = ""
 		if msg.err != nil {
-			m.updateError = msg.err.Error()
+			m.updateError = checkErrorSummary(msg.err)
 		}
+		m.updateLocked = msg.locked
 
 	case updateProgressMsg:
 		m.updateProgress = msg.progress
-		if msg.progress.Stage == "complete" || msg.progress.Stage == "failed" {
+		if msg.progress.Stage.Terminal() {
 			m.updateInProgress = false
 		}
 	}
@@ -101,21 +125,101 @@ func (m Model) View() string {
 // checkForUpdatesCmd creates a command to check for updates.
 func (m Model) checkForUpdatesCmd() tea.Cmd {
 	return func() tea.Msg {
-		checker := update.NewChecker(update.CheckerConfig{
-			GitHubOwner:    "Tfc538",
-			GitHubRepo:     "core-cli",
-			CurrentVersion: m.currentVersion,
-		})
-
-		info, err := checker.Check()
-		return updateCheckCompleteMsg{info, err}
+		// Follow the persisted release channel; fall back to stable on errors
+		cfg, _ := config.LoadUpdate()
+		channel, _ := update.ParseChannel(cfg.Channel)
+
+		// Proxies and CAs from network.json apply here too
+		transport, timeout, err := networkTransport()
+		if err != nil {
+			return updateCheckCompleteMsg{nil, err, nil}
+		}
+
+		// Same source, tokens and check cache as `core update check`, so
+		// startup rarely hits the API
+		checkerConfig := cfg.CheckerConfig(channel, transport, timeout)
+		checkerConfig.CurrentVersion = m.currentVersion
+		checker := update.NewChecker(checkerConfig)
+
+		ctx := m.ctx
+		if ctx == nil {
+			ctx = context.Background()
+		}
+
+		info, err := checker.CheckContext(ctx)
+		return updateCheckCompleteMsg{info, err, updateLockHolder()}
+	}
+}
+
+// updateLockHolder returns the lock held by another process updating CORE
+// CLI, such as `core update apply`, or nil when no update is running.
+func updateLockHolder() *update.LockedError {
+	stateDir, err := config.StateDir()
+	if err != nil {
+		return nil
+	}
+
+	var locked *update.LockedError
+	if errors.As(update.CheckLock(stateDir), &locked) {
+		return locked
+	}
+	return nil
+}
+
+// checkErrorSummary shortens a failed update check for the status bar;
+// `core update check` explains it in full.
+func checkErrorSummary(err error) string {
+	var apiErr *update.APIError
+	if !errors.As(err, &apiErr) {
+		return err.Error()
+	}
+
+	switch {
+	case errors.Is(err, update.ErrRateLimited):
+		if apiErr.RetryAt.IsZero() {
+			return "rate limited"
+		}
+		return "rate limited until " + apiErr.RetryAt.Local().Format("15:04")
+	case errors.Is(err, update.ErrUnauthorized):
+		return apiErr.Host + " denied access"
+	case errors.Is(err, update.ErrRepoNotFound):
+		return "repository not found"
+	case errors.Is(err, update.ErrNetwork):
+		return "cannot reach " + apiErr.Host
+	}
+	return err.Error()
+}
+
+// networkTransport builds the HTTP transport and check timeout from the
+// network configuration shared with the CLI.
+func networkTransport() (*http.Transport, time.Duration, error) {
+	cfg, err := config.LoadNetwork()
+	if err != nil {
+		return nil, 0, err
 	}
+
+	opts, err := cfg.Options()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	checkTimeout, _, err := cfg.Timeouts()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	transport, err := network.NewTransport(opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	return transport, checkTimeout, nil
 }
 
 // updateCheckCompleteMsg is sent when an update check completes.
 type updateCheckCompleteMsg struct {
-	info *update.UpdateInfo
-	err  error
+	info   *update.UpdateInfo
+	err    error
+	locked *update.LockedError
 }
 
 // updateProgressMsg is sent to report update progress.
@@ -132,7 +236,12 @@ func renderTitle() string {
 func renderStatusBar(m Model) string {
 	status := "Status: "
 
-	if !m.hasCheckedUpdate {
+	if m.updateLocked != nil {
+		status += "⏸ Update in progress"
+		if m.updateLocked.PID > 0 {
+			status += fmt.Sprintf(" by PID %d", m.updateLocked.PID)
+		}
+	} else if !m.hasCheckedUpdate {
 		status += "checking for updates..."
 	} else if m.updateError != "" {
 		status += fmt.Sprintf("update check failed: %s", m.updateError)
@@ -3,6 +3,7 @@ package tui
 import (
 	"fmt"
 
+	"github.com/Tfc538/core-cli/internal/engine/update"
 	"github.com/charmbracelet/lipgloss"
 )
 
@@ -47,20 +48,28 @@ func (sb *StatusBar) RenderError(err string) string {
 }
 
 // RenderProgress renders progress information.
-func (sb *StatusBar) RenderProgress(stage string, percent int) string {
+func (sb *StatusBar) RenderProgress(stage update.Stage, percent int) string {
 	switch stage {
-	case "downloading":
+	case update.StageDownloading:
 		bar := lipgloss.NewStyle().
 			Foreground(lipgloss.Color("4")).
 			Render(fmt.Sprintf("⬇ Downloading... %d%%", percent))
 		return bar
-	case "verifying":
+	case update.StageResuming, update.StageRetrying:
+		return "↻ Retrying download..."
+	case update.StageFallback:
+		return "↻ Trying another mirror..."
+	case update.StageVerifying, update.StageVerified:
 		return "🔍 Verifying..."
-	case "replacing":
+	case update.StageExtracting:
+		return "📦 Extracting..."
+	case update.StageReplacing:
 		return "🔄 Replacing..."
-	case "complete":
+	case update.StageVerifyingInstall:
+		return "🧪 Checking install..."
+	case update.StageComplete:
 		return sb.styles.Success.Render("✓ Update complete!")
-	case "failed":
+	case update.StageFailed:
 		return sb.styles.Error.Render("✗ Update failed")
 	}
 	return ""
@@ -63,17 +63,28 @@ func (uv *UpdateView) renderProgressBar(progress update.UpdateProgress) string {
 
 	label := ""
 	switch progress.Stage {
-	case "downloading":
+	case update.StageDownloading:
 		label = "Downloading"
 		if progress.BytesTotal > 0 {
 			mb := progress.BytesDone / 1024 / 1024
 			totalMB := progress.BytesTotal / 1024 / 1024
 			label += fmt.Sprintf(" (%d/%d MB)", mb, totalMB)
 		}
-	case "verifying":
+	case update.StageResuming, update.StageRetrying:
+		label = "Retrying download"
+		if progress.Attempt > 0 {
+			label += fmt.Sprintf(" (attempt %d)", progress.Attempt)
+		}
+	case update.StageFallback:
+		label = "Switching mirror"
+	case update.StageVerifying, update.StageVerified:
 		label = "Verifying"
-	case "replacing":
+	case update.StageExtracting:
+		label = "Extracting"
+	case update.StageReplacing:
 		label = "Replacing"
+	case update.StageVerifyingInstall:
+		label = "Checking install"
 	}
 
 	return fmt.Sprintf("  [%s] %d%% %s", style.Render(bar), progress.Percent, label)
@@ -84,15 +95,30 @@ func (uv *UpdateView) renderStatusMessage(progress update.UpdateProgress) string
 	var msg string
 
 	switch progress.Stage {
-	case "downloading":
+	case update.StageDownloading:
 		msg = "⬇ Downloading update..."
-	case "verifying":
+		if progress.Mirror != "" {
+			msg = fmt.Sprintf("⬇ Downloading update from %s...", progress.Mirror)
+		}
+	case update.StageResuming:
+		msg = "↻ Resuming download..."
+	case update.StageRetrying:
+		msg = "↻ Connection interrupted, retrying download..."
+	case update.StageFallback:
+		msg = fmt.Sprintf("↻ Download failed, trying %s...", progress.Mirror)
+	case update.StageVerifying:
 		msg = "🔍 Verifying checksum..."
-	case "replacing":
+	case update.StageVerified:
+		msg = "✓ Checksum verified"
+	case update.StageExtracting:
+		msg = "📦 Extracting binary..."
+	case update.StageReplacing:
 		msg = "🔄 Installing update..."
-	case "complete":
+	case update.StageVerifyingInstall:
+		msg = "🧪 Checking the installed binary..."
+	case update.StageComplete:
 		return uv.styles.Success.Render("✓ Update completed successfully!")
-	case "failed":
+	case update.StageFailed:
 		return uv.styles.Error.Render(fmt.Sprintf("✗ Update failed: %v", progress.Error))
 	default:
 		msg = "Initializing..."
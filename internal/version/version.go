@@ -2,6 +2,10 @@ package version
 
 import "fmt"
 
+// ModulePath is the Go module CORE CLI is built from, as recorded in the
+// build info of release binaries.
+const ModulePath = "github.com/Tfc538/core-cli"
+
 var (
 	// Version is the semantic version of CORE CLI.
 	// Injected at build time via -X flag: -X github.com/Tfc538/core-cli/internal/version.Version=1.0.0
@@ -14,6 +18,11 @@ var (
 	// BuildDate is the timestamp when the binary was built (RFC3339 format).
 	// Injected at build time: -X github.com/Tfc538/core-cli/internal/version.BuildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)
 	BuildDate = "unknown"
+
+	// UpdatePublicKey is the minisign public key used to verify release signatures.
+	// Injected at build time: -X github.com/Tfc538/core-cli/internal/version.UpdatePublicKey=RWQ...
+	// Development builds leave it empty, which disables signature verification.
+	UpdatePublicKey = ""
 )
 
 // Info represents the version information for CORE CLI.
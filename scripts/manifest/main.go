@@ -0,0 +1,217 @@
+// Command manifest writes a release-manifest.json for the CLI binaries in a
+// dist directory, so updates can be self-hosted on a plain file server.
+//
+//	go run ./scripts/manifest -version 1.2.0 -base-url https://dl.example.com/core/v1.2.0/
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/Tfc538/core-cli/internal/engine/update"
+)
+
+const signatureExt = ".minisig"
+
+func main() {
+	var (
+		distDir  = flag.String("dist", "dist", "Directory produced by make build-all")
+		version  = flag.String("version", "", "Version of the release (required)")
+		channel  = flag.String("channel", "", "Release channel (default: derived from the version)")
+		baseURL  = flag.String("base-url", "", "URL prefix of the assets (default: relative to the manifest)")
+		notes    = flag.String("notes", "", "File holding the release notes")
+		previous = flag.String("previous", "", "Existing manifest whose other releases are kept")
+		output   = flag.String("o", "", "Output file (default: <dist>/release-manifest.json)")
+	)
+	flag.Parse()
+
+	if err := run(*distDir, *version, *channel, *baseURL, *notes, *previous, *output); err != nil {
+		fmt.Fprintf(os.Stderr, "manifest: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(distDir, version, channel, baseURL, notesFile, previous, output string) error {
+	version = update.NormalizeVersion(version)
+	if _, err := semver.NewVersion(version); err != nil {
+		return fmt.Errorf("invalid -version %q: %w", version, err)
+	}
+	if channel != "" {
+		if _, err := update.ParseChannel(channel); err != nil {
+			return err
+		}
+	}
+	if output == "" {
+		output = filepath.Join(distDir, "release-manifest.json")
+	}
+
+	release := update.ManifestRelease{
+		Version: version,
+		Channel: update.Channel(channel),
+		Date:    time.Now().UTC().Format(time.RFC3339),
+	}
+
+	if notesFile != "" {
+		data, err := os.ReadFile(notesFile)
+		if err != nil {
+			return fmt.Errorf("failed to read release notes: %w", err)
+		}
+		release.Notes = string(data)
+	}
+
+	assets, err := collectAssets(filepath.Join(distDir, "core"), baseURL)
+	if err != nil {
+		return err
+	}
+	if len(assets) == 0 {
+		return fmt.Errorf("no binaries found in %s", filepath.Join(distDir, "core"))
+	}
+	release.Assets = assets
+
+	manifest := update.ReleaseManifest{SchemaVersion: update.ManifestSchemaVersion}
+	if previous != "" {
+		existing, err := readManifest(previous)
+		if err != nil {
+			return err
+		}
+		for _, r := range existing.Releases {
+			if update.NormalizeVersion(r.Version) != version {
+				manifest.Releases = append(manifest.Releases, r)
+			}
+		}
+	}
+	manifest.Releases = append(manifest.Releases, release)
+	sortReleases(manifest.Releases)
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := os.WriteFile(output, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	fmt.Printf("✓ Wrote %s (%d assets)\n", output, len(assets))
+	return nil
+}
+
+// collectAssets describes every binary in dir, pairing it with its detached
+// signature when one exists.
+func collectAssets(dir, baseURL string) ([]update.ManifestAsset, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+
+	prefix := "core/"
+	if baseURL != "" {
+		prefix = strings.TrimRight(baseURL, "/") + "/"
+	}
+
+	var assets []update.ManifestAsset
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || strings.HasSuffix(name, signatureExt) {
+			continue
+		}
+
+		path := filepath.Join(dir, name)
+		size, sum, err := digest(path)
+		if err != nil {
+			return nil, err
+		}
+
+		goos, goarch := platformOf(name)
+		asset := update.ManifestAsset{
+			Name:   name,
+			OS:     goos,
+			Arch:   goarch,
+			URL:    prefix + name,
+			Size:   size,
+			SHA256: sum,
+		}
+
+		if _, err := os.Stat(path + signatureExt); err == nil {
+			asset.Signature = prefix + name + signatureExt
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+
+		assets = append(assets, asset)
+	}
+
+	return assets, nil
+}
+
+// platformOf parses core-<os>-<arch>[-<libc>][.ext] asset names.
+func platformOf(name string) (goos, goarch string) {
+	base := name
+	for _, ext := range []string{".tar.gz", ".zip", ".exe"} {
+		base = strings.TrimSuffix(base, ext)
+	}
+
+	base = strings.TrimSuffix(base, "-musl")
+	base = strings.TrimSuffix(base, "-gnu")
+
+	parts := strings.Split(base, "-")
+	if len(parts) < 3 {
+		return "", ""
+	}
+	return parts[len(parts)-2], parts[len(parts)-1]
+}
+
+func digest(path string) (int64, string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+
+	return size, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func readManifest(path string) (update.ReleaseManifest, error) {
+	var manifest update.ReleaseManifest
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return manifest, fmt.Errorf("failed to read previous manifest: %w", err)
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return manifest, fmt.Errorf("invalid previous manifest: %w", err)
+	}
+	if manifest.SchemaVersion > update.ManifestSchemaVersion {
+		return manifest, fmt.Errorf("previous manifest uses unsupported schema version %d", manifest.SchemaVersion)
+	}
+
+	return manifest, nil
+}
+
+// sortReleases orders releases newest first; unparseable versions go last.
+func sortReleases(releases []update.ManifestRelease) {
+	sort.SliceStable(releases, func(i, j int) bool {
+		vi, erri := semver.NewVersion(releases[i].Version)
+		vj, errj := semver.NewVersion(releases[j].Version)
+		if erri != nil || errj != nil {
+			return erri == nil
+		}
+		return vi.GreaterThan(vj)
+	})
+}